@@ -55,7 +55,7 @@ func (q Bits) Bit(i int) bool {
 
 func (q Bits) Slice(from, to int) Bits {
 	ql := q.Len()
-	if ql <= from {
+	if ql <= from || to <= from {
 		return Bits{}
 	}
 	if to > ql {
@@ -68,11 +68,13 @@ func (q Bits) Slice(from, to int) Bits {
 	of := from / _W
 	for i := range x {
 		x[i] = q.b[i+of] >> rr
-		if i+1 < len(q.b) {
+		if i+of+1 < len(q.b) {
 			x[i] |= q.b[i+of+1] << rl
 		}
 	}
-	x[len(x)-1] &= ^uint(0) >> (_W - ((to - from) % _W))
+	if n := (to - from) % _W; n != 0 {
+		x[len(x)-1] &= ^uint(0) >> (_W - n)
+	}
 	r := Bits{x}
 	if r.Len() == 0 {
 		return Bits{}
@@ -83,6 +83,9 @@ func (c Cell) Float() float64 {
 }
 
 func (c Cell) Reals(docopy ...bool) []float32 {
+	if c.Type() == SparseTensorType {
+		return c.Interface().(SparseTensor).Floats32()
+	}
 	if c.Type() != TensorType {
 		panic(zorros.Panic(zorros.New("cell type is not tensor")))
 	}
@@ -99,6 +102,9 @@ func (c Cell) Values() interface{} {
 }
 
 func (c Cell) Volume() int {
+	if c.Type() == SparseTensorType {
+		return c.Interface().(SparseTensor).Len()
+	}
 	if c.Type() != TensorType {
 		panic(zorros.Panic(zorros.New("cell type is not tensor")))
 	}
@@ -88,6 +88,8 @@ func Convert(v reflect.Value, na bool, tp reflect.Type) reflect.Value {
 			return reflect.ValueOf(float32(v.Uint()))
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			return reflect.ValueOf(float32(v.Int()))
+		case reflect.Bool:
+			return reflect.ValueOf(float32(Ife(v.Bool(), 1, 0).(int)))
 		}
 	} else if tp.Kind() == reflect.Float64 {
 		switch v.Kind() {
@@ -103,6 +105,8 @@ func Convert(v reflect.Value, na bool, tp reflect.Type) reflect.Value {
 			return reflect.ValueOf(float64(v.Uint()))
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			return reflect.ValueOf(float64(v.Int()))
+		case reflect.Bool:
+			return reflect.ValueOf(float64(Ife(v.Bool(), 1, 0).(int)))
 		}
 	}
 	return v.Convert(tp)
@@ -11,8 +11,8 @@ func FieldsOf(s interface{}) []string {
 		panic("only struct{} and &struct{} allowed as an argument")
 	}
 	r := []string{}
-	for i := 0; i < v.NumField(); i++ {
-		r = append(r, v.Field(i).Name)
+	for _, f := range BoundFields(v) {
+		r = append(r, f.Name)
 	}
 	return r
 }
@@ -27,8 +27,8 @@ func AsMap(s interface{}) map[string]reflect.Value {
 	}
 	vt := v.Type()
 	r := map[string]reflect.Value{}
-	for i := 0; i < vt.NumField(); i++ {
-		r[vt.Field(i).Name] = v.Field(i)
+	for _, f := range BoundFields(vt) {
+		r[f.Name] = v.FieldByIndex(f.Index)
 	}
 	return r
 }
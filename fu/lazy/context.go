@@ -0,0 +1,61 @@
+package lazy
+
+import (
+	"context"
+	"go4ml.xyz/base/fu"
+	"reflect"
+	"sync"
+)
+
+/*
+WithContext binds stream to the context, when context is cancelled or its deadline expires,
+the upstream is stopped, so producers are finished and inputs are closed,
+and the stream returns ctx.Err()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := source.WithContext(ctx).Parallel().Drain(sink)
+*/
+func (zf Source) WithContext(ctx context.Context) Source {
+	return func() Stream {
+		if err := ctx.Err(); err != nil {
+			return Error(err)
+		}
+		z := zf()
+		once := sync.Once{}
+		stop := func() { once.Do(func() { z(STOP) }) }
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				stop()
+			case <-done:
+			}
+		}()
+		f := fu.AtomicFlag{Value: 0}
+		return func(index uint64) (reflect.Value, error) {
+			if index == STOP {
+				if f.Set() {
+					close(done)
+				}
+				stop()
+				return falseValue, nil
+			}
+			if err := ctx.Err(); err != nil {
+				return falseValue, err
+			}
+			v, err := z(index)
+			if e := ctx.Err(); e != nil {
+				return falseValue, e
+			}
+			return v, err
+		}
+	}
+}
+
+/*
+DrainContext drains stream into sink stopping it when context is done, returns ctx.Err() in this case
+*/
+func (zf Source) DrainContext(ctx context.Context, sink func(reflect.Value) error) error {
+	return zf.WithContext(ctx).Drain(sink)
+}
@@ -83,9 +83,12 @@ func (zf Source) Parallel(concurrency ...int) Source {
 				}
 			}()
 		}
+		f := fu.AtomicFlag{Value: 0}
 		return func(index uint64) (reflect.Value, error) {
 			if index == STOP {
-				close(stop)
+				if f.Set() {
+					close(stop)
+				}
 				return z(STOP)
 			}
 			if x, ok := <-c; ok {
@@ -142,18 +145,26 @@ func (zf Source) Drain(sink func(reflect.Value) error) (err error) {
 
 func Chan(c interface{}, stop ...chan struct{}) Source {
 	return func() Stream {
-		scase := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)}}
+		done := make(chan struct{})
+		scase := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)}, // unblocks waiting on STOP
+		}
 		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
 		return func(index uint64) (v reflect.Value, err error) {
 			if index == STOP {
 				wc.Stop()
-				for _, s := range stop {
-					close(s)
+				if f.Set() {
+					close(done)
+					for _, s := range stop {
+						close(s)
+					}
 				}
 			}
 			if wc.Wait(index) {
-				_, r, ok := reflect.Select(scase)
-				if wc.Inc() && ok {
+				i, r, ok := reflect.Select(scase)
+				if wc.Inc() && ok && i == 0 {
 					return r, nil
 				}
 			}
@@ -0,0 +1,63 @@
+package fu
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+SparseTensor is a sparse vector of float32 values with strictly increasing indices,
+Width is the length of dense vector or 0 if it's unknown
+*/
+type SparseTensor struct {
+	Width   int
+	Indices []int
+	Values  []float32
+}
+
+var SparseTensorType = reflect.TypeOf(SparseTensor{})
+
+/*
+MakeSparseTensor makes sparse vector, indices have to be strictly increasing
+*/
+func MakeSparseTensor(width int, indices []int, values []float32) SparseTensor {
+	return SparseTensor{width, indices, values}
+}
+
+/*
+Len returns length of dense vector, it's Width or last index + 1 if Width is unknown
+*/
+func (t SparseTensor) Len() int {
+	if t.Width > 0 || len(t.Indices) == 0 {
+		return t.Width
+	}
+	return t.Indices[len(t.Indices)-1] + 1
+}
+
+/*
+Floats32 returns values of dense vector
+*/
+func (t SparseTensor) Floats32(...bool) []float32 {
+	r := make([]float32, t.Len())
+	for i, j := range t.Indices {
+		r[j] = t.Values[i]
+	}
+	return r
+}
+
+/*
+Dense converts sparse vector to the float32 tensor 1x1xLen
+*/
+func (t SparseTensor) Dense() Tensor {
+	r := t.Floats32()
+	return MakeFloat32Tensor(1, 1, len(r), r)
+}
+
+func (t SparseTensor) String() string {
+	s := make([]string, len(t.Indices))
+	for i, j := range t.Indices {
+		s[i] = strconv.Itoa(j) + ":" + strconv.FormatFloat(float64(t.Values[i]), 'g', -1, 32)
+	}
+	return "{" + strings.Join(s, " ") + "}"
+}
@@ -61,16 +61,17 @@ func (lrx Struct) With(lr Struct) (r Struct) {
 }
 
 func Wrapper(rt reflect.Type) func(reflect.Value) Struct {
-	L := rt.NumField()
+	fields := BoundFields(rt)
+	L := len(fields)
 	names := make([]string, L)
-	for i := range names {
-		names[i] = rt.Field(i).Name
+	for i, f := range fields {
+		names[i] = f.Name
 	}
 	return func(v reflect.Value) Struct {
 		lr := Struct{Columns: make([]reflect.Value, L), Names: names, Na: Bits{}}
-		for i := range names {
-			x := v.Field(i)
-			lr.Na.Set(i, Isna(x))
+		for i, f := range fields {
+			x, na := FieldValue(v.FieldByIndex(f.Index))
+			lr.Na.Set(i, na)
 			lr.Columns[i] = x
 		}
 		return lr
@@ -81,31 +82,26 @@ var uwrpMu = sync.Mutex{}
 
 func Unwrapper(v reflect.Type) func(lr Struct) reflect.Value {
 	var indecies [][]int
+	fields := BoundFields(v)
 	inif := AtomicFlag{0}
 	return func(lr Struct) reflect.Value {
 		if !inif.State() {
 			uwrpMu.Lock()
 			if !inif.State() {
 				var nd [][]int
-				L := v.NumField()
-				for i := 0; i < L; i++ {
-					vt := v.Field(i)
-					pat := string(vt.Tag)
-					if pat == "" {
-						pat = vt.Name
-					}
-					like := Pattern(pat)
+				for _, f := range fields {
+					like := Pattern(f.Pattern)
 					q := []int{}
 					for i, n := range lr.Names {
 						if like(n) {
 							q = append(q, i)
 						}
 					}
-					if len(q) == 0 {
+					if len(q) == 0 && !f.OmitEmpty {
 						uwrpMu.Unlock()
-						panic(zorros.Panic(zorros.Errorf("Struct does not have filed(s) matched to " + pat)))
+						panic(zorros.Panic(zorros.Errorf("Struct does not have filed(s) matched to " + f.Pattern)))
 					}
-					if vt.Type.Kind() == reflect.Slice {
+					if f.Type.Kind() == reflect.Slice || len(q) == 0 {
 						nd = append(nd, q)
 					} else {
 						nd = append(nd, q[:1])
@@ -119,18 +115,21 @@ func Unwrapper(v reflect.Type) func(lr Struct) reflect.Value {
 
 		x := reflect.New(v).Elem()
 		for i, nd := range indecies {
-			vt := v.Field(i)
-			if vt.Type.Kind() == reflect.Slice {
-				et := vt.Type.Elem()
+			f := fields[i]
+			fv := x.FieldByIndex(f.Index)
+			if len(nd) == 0 {
+				// column is missing, so field is NA
+				SetField(fv, reflect.Value{}, true)
+			} else if f.Type.Kind() != reflect.Slice || len(nd) == 1 && lr.Columns[nd[0]].Type() == TensorType {
+				k := nd[0]
+				SetField(fv, lr.Columns[k], lr.Na.Bit(k))
+			} else {
+				et := f.Type.Elem()
 				a := reflect.MakeSlice(reflect.SliceOf(et), len(nd), len(nd))
 				for j, k := range nd {
 					a.Index(j).Set(Convert(lr.Columns[k], lr.Na.Bit(k), et))
 				}
-				x.Field(i).Set(a)
-			} else {
-				k := nd[0]
-				y := Convert(lr.Columns[k], lr.Na.Bit(k), vt.Type)
-				x.Field(i).Set(y)
+				fv.Set(a)
 			}
 		}
 		return x
@@ -144,6 +143,7 @@ func Transformer(rt reflect.Type) func(reflect.Value, reflect.Value) reflect.Val
 		names  []string
 		update []int
 	)
+	fields := BoundFields(rt)
 	inif := AtomicFlag{0}
 	return func(v reflect.Value, olr reflect.Value) reflect.Value {
 		lrx := olr.Interface().(Struct)
@@ -156,11 +156,9 @@ func Transformer(rt reflect.Type) func(reflect.Value, reflect.Value) reflect.Val
 				for i := range update {
 					update[i] = -1
 				}
-				L := rt.NumField()
-				for i := 0; i < L; i++ {
-					n := rt.Field(i).Name
-					if j := IndexOf(n, names); j < 0 {
-						names = append(names, n)
+				for i, f := range fields {
+					if j := IndexOf(f.Name, names); j < 0 {
+						names = append(names, f.Name)
 						update = append(update, i)
 					} else {
 						update[j] = i
@@ -173,8 +171,8 @@ func Transformer(rt reflect.Type) func(reflect.Value, reflect.Value) reflect.Val
 		lr := Struct{Columns: make([]reflect.Value, len(names)), Names: names, Na: lrx.Na.Copy()}
 		for i := range names {
 			if j := update[i]; j >= 0 {
-				x := v.Field(j)
-				lr.Na.Set(i, Isna(x))
+				x, na := FieldValue(v.FieldByIndex(fields[j].Index))
+				lr.Na.Set(i, na)
 				lr.Columns[i] = x
 			} else {
 				lr.Columns[i] = lrx.Columns[i]
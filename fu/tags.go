@@ -0,0 +1,217 @@
+package fu
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+FieldTag describes how struct field is bound to column
+
+	struct {
+		Id      int               `table:"customer_id"` // column customer_id
+		Comment *string           `table:",omitempty"`  // column Comment can be missing, nil is NA
+		Rate    sql.NullFloat64                          // column Rate, Valid is false for NA
+		Cache   []byte            `table:"-"`           // not bound
+		Feature []float32         `Feature*`            // raw tag is the pattern of columns filling the field
+	}
+*/
+type FieldTag struct {
+	Name      string // column name
+	Pattern   string // pattern of column names matched when struct is filled from row
+	OmitEmpty bool   // missing column is tolerated
+	Skip      bool   // field is not bound to column
+}
+
+/*
+TagOf returns binding of struct field
+*/
+func TagOf(f reflect.StructField) FieldTag {
+	if f.PkgPath != "" {
+		return FieldTag{Name: f.Name, Skip: true}
+	}
+	if s, ok := f.Tag.Lookup("table"); ok {
+		if s == "-" {
+			return FieldTag{Name: f.Name, Skip: true}
+		}
+		p := strings.Split(s, ",")
+		t := FieldTag{Name: p[0]}
+		if t.Name == "" {
+			t.Name = f.Name
+		}
+		t.Pattern = t.Name
+		for _, o := range p[1:] {
+			if o == "omitempty" {
+				t.OmitEmpty = true
+			}
+		}
+		return t
+	}
+	if f.Tag != "" && !strings.Contains(string(f.Tag), ":\"") {
+		return FieldTag{Name: f.Name, Pattern: string(f.Tag)}
+	}
+	return FieldTag{Name: f.Name, Pattern: f.Name}
+}
+
+/*
+BoundField is a struct field bound to column
+*/
+type BoundField struct {
+	FieldTag
+	Index []int        // index sequence of field for reflect.Value.FieldByIndex
+	Type  reflect.Type // type of field
+}
+
+/*
+BoundFields returns fields bound to columns, nested structs are flattened
+
+	struct {
+		Name    string
+		Address struct{ City, Street string } // columns Address.City and Address.Street
+		Meta                                  // fields of embedded struct are bound like fields of struct
+		Vector  []float32                     // column of fu.Tensor
+	}
+
+Structs implementing fmt.Stringer (like time.Time or fu.Tensor) and sql.Null*-style structs are not flattened.
+*/
+func BoundFields(rt reflect.Type) []BoundField {
+	return boundFields(rt, "", nil)
+}
+
+func boundFields(rt reflect.Type, prefix string, index []int) (r []BoundField) {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		t := TagOf(f)
+		x := append(append(make([]int, 0, len(index)+1), index...), i)
+		tag, tagged := f.Tag.Lookup("table")
+		// exported fields of embedded struct are accessible even if struct type is not exported
+		if flattened(f.Type) && tag != "-" && (f.PkgPath == "" || f.Anonymous) {
+			if f.Anonymous && !tagged {
+				r = append(r, boundFields(f.Type, prefix, x)...)
+			} else {
+				r = append(r, boundFields(f.Type, prefix+t.Name+".", x)...)
+			}
+		} else if !t.Skip {
+			t.Name, t.Pattern = prefix+t.Name, prefix+t.Pattern
+			r = append(r, BoundField{t, x, f.Type})
+		}
+	}
+	return
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+/*
+flattened returns true if fields of struct type are bound to columns instead of struct itself
+*/
+func flattened(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != StructType && !nullStruct(t) &&
+		!t.Implements(stringerType) && !reflect.PtrTo(t).Implements(stringerType)
+}
+
+/*
+tensorSlice returns true for slice of floats which is bound to tensor column
+*/
+func tensorSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && (t.Elem() == Float32 || t.Elem() == Float64)
+}
+
+/*
+nullStruct returns true if type is sql.Null*-style struct having value and Valid fields
+*/
+func nullStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 2 &&
+		t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool
+}
+
+/*
+FieldType returns type of column for field type, it's the type of value for pointers and sql.Null*-style structs
+*/
+func FieldType(t reflect.Type) reflect.Type {
+	if tensorSlice(t) {
+		return TensorType
+	}
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	if nullStruct(t) {
+		return t.Field(0).Type
+	}
+	return t
+}
+
+/*
+FieldValue returns value of field and NA flag, nil pointers and invalid sql.Null*-style values are NA
+*/
+func FieldValue(f reflect.Value) (reflect.Value, bool) {
+	t := f.Type()
+	if tensorSlice(t) {
+		if f.IsNil() {
+			return reflect.ValueOf(Tensor{}), true
+		}
+		if x, ok := f.Interface().([]float32); ok {
+			return reflect.ValueOf(MakeFloat32Tensor(1, 1, len(x), append([]float32{}, x...))), false
+		}
+		x := f.Interface().([]float64)
+		return reflect.ValueOf(MakeFloat64Tensor(1, 1, len(x), append([]float64{}, x...))), false
+	}
+	if t.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return reflect.Zero(t.Elem()), true
+		}
+		return f.Elem(), Isna(f.Elem())
+	}
+	if nullStruct(t) {
+		return f.Field(0), !f.Field(1).Bool()
+	}
+	return f, Isna(f)
+}
+
+/*
+SetField sets field to value converted to field type, pointers become nil and sql.Null*-style values invalid for NA
+*/
+func SetField(f reflect.Value, v reflect.Value, na bool) {
+	t := f.Type()
+	if tensorSlice(t) && (na || v.Type() == TensorType) {
+		if na {
+			f.Set(reflect.Zero(t))
+			return
+		}
+		tv := v.Interface().(Tensor)
+		if y, ok := tv.Values().([]float64); ok && t.Elem() == Float64 {
+			f.Set(reflect.ValueOf(append([]float64{}, y...)))
+			return
+		}
+		x := tv.Floats32(true)
+		if t.Elem() == Float32 {
+			f.Set(reflect.ValueOf(x))
+			return
+		}
+		r := make([]float64, len(x))
+		for i, q := range x {
+			r[i] = float64(q)
+		}
+		f.Set(reflect.ValueOf(r))
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		if na {
+			f.Set(reflect.Zero(t))
+		} else {
+			p := reflect.New(t.Elem())
+			p.Elem().Set(Convert(v, false, t.Elem()))
+			f.Set(p)
+		}
+		return
+	}
+	if nullStruct(t) {
+		f.Set(reflect.Zero(t))
+		if !na {
+			f.Field(0).Set(Convert(v, false, t.Field(0).Type))
+			f.Field(1).SetBool(true)
+		}
+		return
+	}
+	f.Set(Convert(v, na, t))
+}
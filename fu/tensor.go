@@ -1,6 +1,9 @@
 package fu
 
 import (
+	"encoding/binary"
+	"go4ml.xyz/zorros"
+	"math"
 	"reflect"
 	"strconv"
 )
@@ -361,3 +364,99 @@ func MakeIntTensor(channels, height, width int, values []int, docopy ...bool) Te
 }
 
 var TensorType = reflect.TypeOf(Tensor{})
+
+/*
+MarshalBinary encodes tensor as the magic byte, three uint32 dimensions and little-endian values
+*/
+func (t Tensor) MarshalBinary() ([]byte, error) {
+	c, h, w := t.Dimension()
+	b := make([]byte, 13, 13+t.Volume()*8)
+	b[0] = t.Magic()
+	binary.LittleEndian.PutUint32(b[1:], uint32(c))
+	binary.LittleEndian.PutUint32(b[5:], uint32(h))
+	binary.LittleEndian.PutUint32(b[9:], uint32(w))
+	switch x := t.Values().(type) {
+	case []float32:
+		for _, v := range x {
+			b = appendUint32(b, math.Float32bits(v))
+		}
+	case []float64:
+		for _, v := range x {
+			b = appendUint64(b, math.Float64bits(v))
+		}
+	case []byte:
+		b = append(b, x...)
+	case []Fixed8:
+		for _, v := range x {
+			b = append(b, byte(v.int8))
+		}
+	case []int:
+		for _, v := range x {
+			b = appendUint64(b, uint64(v))
+		}
+	default:
+		return nil, zorros.Errorf("unsupported tensor type %v", t.Type())
+	}
+	return b, nil
+}
+
+/*
+UnmarshalBinary decodes tensor encoded by MarshalBinary
+*/
+func (t *Tensor) UnmarshalBinary(b []byte) error {
+	if len(b) < 13 {
+		return zorros.New("binary tensor is too short")
+	}
+	magic := b[0]
+	c := int(binary.LittleEndian.Uint32(b[1:]))
+	h := int(binary.LittleEndian.Uint32(b[5:]))
+	w := int(binary.LittleEndian.Uint32(b[9:]))
+	vol := c * h * w
+	size := map[byte]int{'f': 4, 'F': 8, 'u': 1, '8': 1, 'i': 8}[magic]
+	if size == 0 {
+		return zorros.Errorf("unknown tensor magic %q", magic)
+	}
+	b = b[13:]
+	if len(b) != vol*size {
+		return zorros.Errorf("binary tensor has %d bytes of values but %d expected", len(b), vol*size)
+	}
+	switch magic {
+	case 'f':
+		v := make([]float32, vol)
+		for i := range v {
+			v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+		}
+		*t = MakeFloat32Tensor(c, h, w, v)
+	case 'F':
+		v := make([]float64, vol)
+		for i := range v {
+			v[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[i*8:]))
+		}
+		*t = MakeFloat64Tensor(c, h, w, v)
+	case 'u':
+		v := make([]byte, vol)
+		copy(v, b)
+		*t = MakeByteTensor(c, h, w, v)
+	case '8':
+		v := make([]Fixed8, vol)
+		for i := range v {
+			v[i] = Fixed8{int8(b[i])}
+		}
+		*t = MakeFixed8Tensor(c, h, w, v)
+	case 'i':
+		v := make([]int, vol)
+		for i := range v {
+			v[i] = int(binary.LittleEndian.Uint64(b[i*8:]))
+		}
+		*t = MakeIntTensor(c, h, w, v)
+	}
+	return nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v)), uint32(v>>32))
+}
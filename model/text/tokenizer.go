@@ -0,0 +1,81 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Tokenizer splits text into words and generates n-grams of words,
+words are sequences of letters and digits
+*/
+type Tokenizer struct {
+	Lowercase bool     // converts words to lower case
+	MinLength int      // skips words shorter than MinLength runes
+	StopWords []string // skips these words, they are lowercased too if Lowercase is set
+	MinGram   int      // minimal count of words in n-gram, 1 by default
+	MaxGram   int      // maximal count of words in n-gram, MinGram by default
+}
+
+/*
+Words splits text into words skipping short and stop words
+*/
+func (tk Tokenizer) Words(text string) []string {
+	if tk.Lowercase {
+		text = strings.ToLower(text)
+	}
+	stop := map[string]bool{}
+	for _, w := range tk.StopWords {
+		if tk.Lowercase {
+			w = strings.ToLower(w)
+		}
+		stop[w] = true
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	r := words[:0]
+	for _, w := range words {
+		if !stop[w] && utf8.RuneCountInString(w) >= tk.MinLength {
+			r = append(r, w)
+		}
+	}
+	return r
+}
+
+/*
+Tokenize splits text into words and returns n-grams of words joined by space
+
+	text.Tokenizer{Lowercase: true, MaxGram: 2}.Tokenize("Hello, World!")
+	-> []string{"hello", "world", "hello world"}
+*/
+func (tk Tokenizer) Tokenize(text string) []string {
+	min := tk.MinGram
+	if min <= 0 {
+		min = 1
+	}
+	max := tk.MaxGram
+	if max < min {
+		max = min
+	}
+	return NGrams(tk.Words(text), min, max)
+}
+
+/*
+NGrams returns all n-grams of words with n in range [min,max], words of n-gram are joined by space
+
+	NGrams([]string{"a","b","c"},1,2) -> []string{"a","b","c","a b","b c"}
+*/
+func NGrams(words []string, min, max int) []string {
+	if min == 1 && max == 1 {
+		return words
+	}
+	r := []string{}
+	for n := min; n <= max; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			r = append(r, strings.Join(words[i:i+n], " "))
+		}
+	}
+	return r
+}
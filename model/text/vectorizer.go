@@ -0,0 +1,288 @@
+package text
+
+import (
+	"encoding/json"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/model"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+)
+
+/*
+Vectorizer defines conversion of text column into features column,
+it uses fitted vocabulary of terms or hashing of terms if Hashing is not 0
+*/
+type Vectorizer struct {
+	Column      string // text column
+	Output      string // features column, Features by default
+	Tokenizer   Tokenizer
+	Hashing     int     // width of hashing space, vocabulary is fitted if it's 0
+	MinDf       int     // skips terms containing in less than MinDf documents
+	MaxDf       float64 // skips terms containing in more than MaxDf fraction of documents, 1 by default
+	MaxFeatures int     // keeps only MaxFeatures the most frequent terms if not 0
+	TfIdf       bool    // weights term frequencies by inverse document frequency
+	Binary      bool    // uses 1 instead of term frequency
+	Normalize   bool    // normalizes vectors to unit length
+	Sparse      bool    // produces fu.SparseTensor instead of dense float32 fu.Tensor
+}
+
+/*
+Model is a fitted vectorizer, it's a prediction model mapping text column to features column
+
+	m, err := text.Vectorizer{
+		Column:    "Text",
+		Tokenizer: text.Tokenizer{Lowercase: true, MaxGram: 2},
+		MinDf:     2,
+		TfIdf:     true,
+		Sparse:    true,
+	}.Fit(dataset)
+	features := dataset.Lazy().BatchTransform(1000, m.FeaturesMapper)
+*/
+type Model struct {
+	Vectorizer
+	Terms     []string `json:",omitempty"` // vocabulary terms ordered by feature index
+	Df        []int    `json:",omitempty"` // document frequency of features
+	Documents int      // count of documents the model is fitted on
+	index     map[string]int
+}
+
+/*
+Fit builds vocabulary and counts document frequencies of features,
+source can be nil for hashing without TF-IDF weighting
+*/
+func (v Vectorizer) Fit(source tables.AnyData) (m *Model, err error) {
+	m = &Model{Vectorizer: v}
+	if source == nil {
+		if v.Hashing > 0 && !v.TfIdf {
+			return
+		}
+		return nil, zorros.Errorf("vectorizer requires data to fit")
+	}
+	df := map[string]int{}
+	var hdf []int
+	if v.Hashing > 0 {
+		hdf = make([]int, v.Hashing)
+	}
+	err = source.Lazy().Foreach(func(lr fu.Struct) error {
+		j := lr.Pos(v.Column)
+		if j < 0 {
+			return zorros.Errorf("text column %v does not exist", v.Column)
+		}
+		m.Documents++
+		if lr.Na.Bit(j) {
+			return nil
+		}
+		seen := map[string]bool{}
+		hseen := map[int]bool{}
+		for _, t := range v.Tokenizer.Tokenize(lr.Columns[j].String()) {
+			if v.Hashing > 0 {
+				if k := m.hash(t); !hseen[k] {
+					hseen[k] = true
+					hdf[k]++
+				}
+			} else if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v.Hashing > 0 {
+		m.Df = hdf
+		return
+	}
+	maxDf := v.MaxDf
+	if maxDf <= 0 {
+		maxDf = 1
+	}
+	for t, n := range df {
+		if n >= v.MinDf && float64(n) <= maxDf*float64(m.Documents) {
+			m.Terms = append(m.Terms, t)
+		}
+	}
+	if v.MaxFeatures > 0 && len(m.Terms) > v.MaxFeatures {
+		sort.Slice(m.Terms, func(i, j int) bool {
+			a, b := df[m.Terms[i]], df[m.Terms[j]]
+			return a > b || (a == b && m.Terms[i] < m.Terms[j])
+		})
+		m.Terms = m.Terms[:v.MaxFeatures]
+	}
+	sort.Strings(m.Terms)
+	m.Df = make([]int, len(m.Terms))
+	for i, t := range m.Terms {
+		m.Df[i] = df[t]
+	}
+	m.indexTerms()
+	return
+}
+
+func (m *Model) indexTerms() {
+	m.index = make(map[string]int, len(m.Terms))
+	for i, t := range m.Terms {
+		m.index[t] = i
+	}
+}
+
+func (m *Model) hash(term string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(term))
+	return int(h.Sum32() % uint32(m.Hashing))
+}
+
+/*
+Width returns count of features
+*/
+func (m *Model) Width() int {
+	if m.Hashing > 0 {
+		return m.Hashing
+	}
+	return len(m.Terms)
+}
+
+/*
+Index returns feature index of the term
+*/
+func (m *Model) Index(term string) (int, bool) {
+	if m.Hashing > 0 {
+		return m.hash(term), true
+	}
+	j, ok := m.index[term]
+	return j, ok
+}
+
+/*
+Idf returns smoothed inverse document frequency of the feature, log((1+n)/(1+df))+1
+*/
+func (m *Model) Idf(j int) float32 {
+	return float32(math.Log(float64(1+m.Documents)/float64(1+m.Df[j])) + 1)
+}
+
+/*
+Vectorize converts text into sparse vector of features
+*/
+func (m *Model) Vectorize(text string) fu.SparseTensor {
+	tf := map[int]float32{}
+	for _, t := range m.Tokenizer.Tokenize(text) {
+		if j, ok := m.Index(t); ok {
+			tf[j]++
+		}
+	}
+	r := fu.SparseTensor{Width: m.Width(), Indices: make([]int, 0, len(tf))}
+	for j := range tf {
+		r.Indices = append(r.Indices, j)
+	}
+	sort.Ints(r.Indices)
+	r.Values = make([]float32, len(r.Indices))
+	norm := 0.0
+	for i, j := range r.Indices {
+		v := tf[j]
+		if m.Binary {
+			v = 1
+		}
+		if m.TfIdf {
+			v *= m.Idf(j)
+		}
+		r.Values[i] = v
+		norm += float64(v) * float64(v)
+	}
+	if m.Normalize && norm > 0 {
+		n := float32(math.Sqrt(norm))
+		for i := range r.Values {
+			r.Values[i] /= n
+		}
+	}
+	return r
+}
+
+/*
+Features returns the text column as the only feature the model uses
+*/
+func (m *Model) Features() []string {
+	return []string{m.Column}
+}
+
+/*
+Predicted returns name of features column
+*/
+func (m *Model) Predicted() string {
+	if m.Output == "" {
+		return "Features"
+	}
+	return m.Output
+}
+
+/*
+FeaturesMapper returns mapper replacing the text column by the features column
+*/
+func (m *Model) FeaturesMapper(int) (tables.FeaturesMapper, error) {
+	return tables.LambdaMapper(func(t *tables.Table) (*tables.Table, error) {
+		c, ok := t.ColIfExists(m.Column)
+		if !ok {
+			return nil, zorros.Errorf("text column %v does not exist", m.Column)
+		}
+		texts := c.Strings()
+		var column interface{}
+		if m.Sparse {
+			x := make([]fu.SparseTensor, len(texts))
+			for i, s := range texts {
+				x[i] = m.Vectorize(s)
+			}
+			column = x
+		} else {
+			x := make([]fu.Tensor, len(texts))
+			for i, s := range texts {
+				x[i] = m.Vectorize(s).Dense()
+			}
+			column = x
+		}
+		return t.Without(m.Column).With(tables.Col(column), m.Predicted()), nil
+	}), nil
+}
+
+const modelFile = "vectorizer.json"
+
+/*
+Memorize writes fitted vectorizer into models collection
+*/
+func (m *Model) Memorize(c *model.CollectionWriter) error {
+	return c.Add(modelFile, func(wr io.Writer) error {
+		return json.NewEncoder(wr).Encode(m)
+	})
+}
+
+/*
+Objectify reads fitted vectorizer from models collection
+
+	pm, err := model.Objectify(iokit.File("model.zip"), model.ObjectifyMap{"text": text.Objectify})
+*/
+func Objectify(c map[string]iokit.Input) (model.PredictionModel, error) {
+	input, ok := c[modelFile]
+	if !ok {
+		return nil, zorros.Errorf("collection does not have %v", modelFile)
+	}
+	rd, err := input.Open()
+	if err != nil {
+		return nil, zorros.Trace(err)
+	}
+	defer rd.Close()
+	m := &Model{}
+	if err = json.NewDecoder(rd).Decode(m); err != nil {
+		return nil, zorros.Wrapf(err, "failed to decode vectorizer: %s", err.Error())
+	}
+	if m.Hashing == 0 && len(m.Df) != len(m.Terms) {
+		return nil, zorros.Errorf("vectorizer is corrupted")
+	}
+	m.indexTerms()
+	return m, nil
+}
+
+var _ model.PredictionModel = (*Model)(nil)
+var _ model.Mnemosyne = (*Model)(nil)
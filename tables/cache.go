@@ -0,0 +1,249 @@
+package tables
+
+import (
+	"bufio"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/zorros"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Fingerprint identifies upstream of cache, cached rows are written again when fingerprint changes
+type Fingerprint string
+
+const cacheMagic = "go4ml.rows.1\n"
+
+/*
+FilesFingerprint makes fingerprint from names, sizes and modification times of files
+*/
+func FilesFingerprint(paths ...string) Fingerprint {
+	s := make([]string, len(paths))
+	for i, p := range paths {
+		if st, err := os.Stat(p); err != nil {
+			s[i] = p + ":none"
+		} else {
+			s[i] = fmt.Sprintf("%v:%d:%d", p, st.Size(), st.ModTime().UnixNano())
+		}
+	}
+	return Fingerprint(strings.Join(s, ";"))
+}
+
+/*
+Cache writes stream into file on the first iteration and replays rows from file on the next ones
+
+	source := csv.Source(iokit.File("dataset.csv"), csv.Float32("*")).
+		Map(prepare).
+		Cache("dataset.rows", tables.FilesFingerprint("dataset.csv"), tables.Fingerprint("prepare v2"))
+
+If path is empty, rows are cached in temporary file which is removed by finalizer
+when the cached stream and all its iterations are not referenced anymore.
+Cache file is replaced only when stream is read to the end without errors,
+so stopped or failed iteration reads upstream again on the next time.
+File keeps fingerprint of upstream (all Fingerprint options joined),
+and it's ignored and written again if fingerprint is changed.
+Columns have to be numeric, string, bool, time.Time, fu.Tensor or fu.Fixed8, NA bits are preserved.
+*/
+func (zf Lazy) Cache(path string, opts ...interface{}) Lazy {
+	fingerprints := []string{}
+	for _, o := range opts {
+		if x, ok := o.(Fingerprint); ok {
+			fingerprints = append(fingerprints, string(x))
+		}
+	}
+	fingerprint := strings.Join(fingerprints, "\n")
+	if path == "" {
+		return zf.tempCache(fingerprint)
+	}
+	return func() lazy.Stream {
+		return cacheStream(zf, path, fingerprint)
+	}
+}
+
+func cacheStream(zf Lazy, path, fingerprint string) lazy.Stream {
+	if f, rd, c := openCache(path, fingerprint); f != nil {
+		return replayCache(f, rd, c)
+	}
+	return writeCache(zf(), path, fingerprint)
+}
+
+/*
+tempCache is the temporary cache file removed by finalizer when the cached stream is not referenced anymore
+*/
+type tempCache struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+func (zf Lazy) tempCache(fingerprint string) Lazy {
+	tc := &tempCache{}
+	runtime.SetFinalizer(tc, func(tc *tempCache) {
+		if tc.path != "" {
+			os.Remove(tc.path)
+		}
+	})
+	return func() lazy.Stream {
+		tc.once.Do(func() {
+			var f *os.File
+			if f, tc.err = ioutil.TempFile("", "go4ml-cache-*.rows"); tc.err == nil {
+				tc.path = f.Name()
+				f.Close()
+			}
+		})
+		if tc.err != nil {
+			return lazy.Error(zorros.Trace(tc.err))
+		}
+		z := cacheStream(zf, tc.path, fingerprint)
+		return func(index uint64) (reflect.Value, error) {
+			// file is not removed while stream is iterated
+			defer runtime.KeepAlive(tc)
+			return z(index)
+		}
+	}
+}
+
+/*
+openCache opens cache file and reads its header, it returns nil if file does not exist or it's invalid
+*/
+func openCache(path, fingerprint string) (*os.File, *bufio.Reader, *rowCodec) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil
+	}
+	rd := bufio.NewReader(f)
+	magic := make([]byte, len(cacheMagic))
+	if _, err = io.ReadFull(rd, magic); err == nil && string(magic) == cacheMagic {
+		var fp []byte
+		if fp, err = readBytes(rd); err == nil && string(fp) == fingerprint {
+			var c *rowCodec
+			if c, err = readRowCodec(rd); err == nil {
+				return f, rd, c
+			}
+		}
+	}
+	f.Close()
+	return nil, nil, nil
+}
+
+func replayCache(f *os.File, rd *bufio.Reader, c *rowCodec) lazy.Stream {
+	wc := fu.WaitCounter{Value: 0}
+	closed := fu.AtomicFlag{Value: 0}
+	return func(index uint64) (reflect.Value, error) {
+		if index == lazy.STOP {
+			wc.Stop()
+			if closed.Set() {
+				f.Close()
+			}
+			return reflect.ValueOf(false), nil
+		}
+		if !wc.Wait(index) {
+			return reflect.ValueOf(false), nil
+		}
+		if len(c.types) == 0 {
+			// empty stream
+			wc.Stop()
+			return reflect.ValueOf(false), nil
+		}
+		lr, err := c.decode(rd)
+		if err != nil {
+			wc.Stop()
+			if err == io.EOF {
+				err = nil
+			}
+			return reflect.ValueOf(false), err
+		}
+		wc.Inc()
+		return reflect.ValueOf(lr), nil
+	}
+}
+
+/*
+writeCache passes rows of upstream through writing them into temporary file
+which replaces cache file when stream reaches the end
+*/
+func writeCache(z lazy.Stream, path, fingerprint string) lazy.Stream {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		z(lazy.STOP)
+		return lazy.Error(zorros.Trace(err))
+	}
+	wr := bufio.NewWriter(tmp)
+	wr.WriteString(cacheMagic)
+	writeBytes(wr, []byte(fingerprint))
+	var c *rowCodec
+	finish := func(ok bool) (err error) {
+		if ok {
+			if c == nil {
+				err = (&rowCodec{}).writeHeader(wr)
+			}
+			err = fu.Fnze(err, wr.Flush())
+			err = fu.Fnze(err, tmp.Close())
+			if err == nil {
+				if err = os.Rename(tmp.Name(), path); err == nil {
+					return
+				}
+			}
+			err = zorros.Wrapf(err, "failed to write cache %v: %s", path, err.Error())
+		} else {
+			tmp.Close()
+		}
+		os.Remove(tmp.Name())
+		return
+	}
+	wc := fu.WaitCounter{Value: 0}
+	f := fu.AtomicFlag{Value: 0}
+	fail := func(err error) (reflect.Value, error) {
+		wc.Stop()
+		if f.Set() {
+			finish(false)
+		}
+		return reflect.ValueOf(false), err
+	}
+	return func(index uint64) (v reflect.Value, err error) {
+		if index == lazy.STOP {
+			fail(nil)
+			return z(index)
+		}
+		v, err = z(index)
+		// rows are written in order of indices
+		if !wc.Wait(index) {
+			return reflect.ValueOf(false), nil
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				wc.Inc()
+				return
+			}
+			wc.Stop()
+			if f.Set() {
+				err = finish(true)
+			}
+			return
+		}
+		lr := v.Interface().(fu.Struct)
+		if c == nil {
+			if c, err = newRowCodec(lr); err == nil {
+				err = c.writeHeader(wr)
+			}
+		}
+		if err == nil {
+			err = c.encode(wr, lr)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		wc.Inc()
+		return
+	}
+}
@@ -0,0 +1,399 @@
+package tables
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/zorros"
+	"math"
+	"reflect"
+	"sort"
+)
+
+/*
+values returns values of numeric column as float64,
+it works with slices directly to avoid reflection per cell
+*/
+func (c *Column) values() []float64 {
+	n := c.Len()
+	r := make([]float64, n)
+	switch x := c.column.Interface().(type) {
+	case []float64:
+		copy(r, x)
+	case []float32:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []int:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []int8:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []int16:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []int32:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []int64:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []uint:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []uint8:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []uint16:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []uint32:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []uint64:
+		for i, v := range x {
+			r[i] = float64(v)
+		}
+	case []fu.Fixed8:
+		for i, v := range x {
+			r[i] = float64(v.Float32())
+		}
+	case []bool:
+		for i, v := range x {
+			if v {
+				r[i] = 1
+			}
+		}
+	default:
+		panic(zorros.Panic(zorros.Errorf("column of type %v is not numeric", c.Type())))
+	}
+	return r
+}
+
+/*
+operand returns values of column or scalar operand broadcasted to length n
+*/
+func operand(x interface{}, n int) (r []float64, na fu.Bits) {
+	if c, ok := x.(*Column); ok {
+		if c.Len() != n {
+			panic(zorros.Panic(zorros.Errorf("column length %d does not match %d", c.Len(), n)))
+		}
+		return c.values(), c.na
+	}
+	v := reflect.ValueOf(x)
+	f := 0.0
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	default:
+		panic(zorros.Panic(zorros.Errorf("operand of type %v is not numeric", v.Type())))
+	}
+	r = make([]float64, n)
+	for i := range r {
+		r[i] = f
+	}
+	return
+}
+
+/*
+result makes column of float32 if column is float32 and operand is float32 column or scalar,
+otherwise it makes column of float64, NA values are NaN
+*/
+func (c *Column) result(r []float64, na fu.Bits, x interface{}) *Column {
+	f32 := c.Type() == fu.Float32
+	if o, ok := x.(*Column); ok && o.Type() != fu.Float32 {
+		f32 = false
+	}
+	for i := range r {
+		if na.Bit(i) {
+			r[i] = math.NaN()
+		}
+	}
+	if f32 {
+		q := make([]float32, len(r))
+		for i, v := range r {
+			q[i] = float32(v)
+		}
+		return &Column{reflect.ValueOf(q), na}
+	}
+	return &Column{reflect.ValueOf(r), na}
+}
+
+func (c *Column) arithmetic(x interface{}, op func(a, b float64) float64) *Column {
+	a := c.values()
+	b, nb := operand(x, len(a))
+	na := c.na.Copy()
+	na.Or_(nb)
+	for i := range a {
+		a[i] = op(a[i], b[i])
+	}
+	return c.result(a, na, x)
+}
+
+/*
+Add returns new column with sum of column and other column or scalar
+
+	t := tables.New([]struct{Age int; Rate float32}{{32,1.2},{44,1.5}})
+	t = t.With(t.Col("Rate").Mul(t.Col("Age")).Add(1), "Score")
+
+Arithmetic operations return float32 column if column is float32 and operand is float32 column or scalar,
+otherwise they return float64 column. Result is NA if any of operands is NA.
+*/
+func (c *Column) Add(x interface{}) *Column {
+	return c.arithmetic(x, func(a, b float64) float64 { return a + b })
+}
+
+// Sub returns new column with difference of column and other column or scalar
+func (c *Column) Sub(x interface{}) *Column {
+	return c.arithmetic(x, func(a, b float64) float64 { return a - b })
+}
+
+// Mul returns new column with product of column and other column or scalar
+func (c *Column) Mul(x interface{}) *Column {
+	return c.arithmetic(x, func(a, b float64) float64 { return a * b })
+}
+
+// Div returns new column with quotient of column and other column or scalar, division by zero gives Inf or NaN
+func (c *Column) Div(x interface{}) *Column {
+	return c.arithmetic(x, func(a, b float64) float64 { return a / b })
+}
+
+func (c *Column) apply(f func(float64) float64) *Column {
+	a := c.values()
+	for i, v := range a {
+		a[i] = f(v)
+	}
+	return c.result(a, c.na.Copy(), nil)
+}
+
+// Abs returns new column with absolute values
+func (c *Column) Abs() *Column {
+	return c.apply(math.Abs)
+}
+
+// Log returns new column with natural logarithms of values
+func (c *Column) Log() *Column {
+	return c.apply(math.Log)
+}
+
+// Clip returns new column with values limited by min and max
+func (c *Column) Clip(min, max float64) *Column {
+	return c.apply(func(v float64) float64 { return math.Max(min, math.Min(max, v)) })
+}
+
+func (c *Column) compare(x interface{}, op func(a, b float64) bool, sop func(a, b string) bool) *Column {
+	n := c.Len()
+	r := make([]bool, n)
+	na := c.na.Copy()
+	if s, ok := c.column.Interface().([]string); ok {
+		var b []string
+		switch o := x.(type) {
+		case string:
+			b = make([]string, n)
+			for i := range b {
+				b[i] = o
+			}
+		case *Column:
+			if b, ok = o.column.Interface().([]string); !ok || len(b) != n {
+				panic(zorros.Panic(zorros.Errorf("string column can be compared only with string column of the same length")))
+			}
+			na.Or_(o.na)
+		default:
+			panic(zorros.Panic(zorros.Errorf("string column can't be compared with %v", reflect.TypeOf(x))))
+		}
+		for i := range r {
+			r[i] = !na.Bit(i) && sop(s[i], b[i])
+		}
+		return &Column{reflect.ValueOf(r), na}
+	}
+	a := c.values()
+	b, nb := operand(x, n)
+	na.Or_(nb)
+	for i := range r {
+		r[i] = !na.Bit(i) && op(a[i], b[i])
+	}
+	return &Column{reflect.ValueOf(r), na}
+}
+
+/*
+Gt returns bool column which is true where column is greater than other column or scalar
+
+	t := tables.New([]struct{Name string; Age int}{{"Ivanov",32},{"Petrov",44}})
+	t.Col("Age").Gt(40).Bools() -> {false, true}
+	t.Col("Name").Eq("Ivanov").Bools() -> {true, false}
+
+Numeric columns are compared with numeric columns and scalars, string columns with string columns and strings.
+Result is false and NA if any of operands is NA.
+*/
+func (c *Column) Gt(x interface{}) *Column {
+	return c.compare(x, func(a, b float64) bool { return a > b }, func(a, b string) bool { return a > b })
+}
+
+// Ge returns bool column which is true where column is greater than or equal to other column or scalar
+func (c *Column) Ge(x interface{}) *Column {
+	return c.compare(x, func(a, b float64) bool { return a >= b }, func(a, b string) bool { return a >= b })
+}
+
+// Lt returns bool column which is true where column is less than other column or scalar
+func (c *Column) Lt(x interface{}) *Column {
+	return c.compare(x, func(a, b float64) bool { return a < b }, func(a, b string) bool { return a < b })
+}
+
+// Le returns bool column which is true where column is less than or equal to other column or scalar
+func (c *Column) Le(x interface{}) *Column {
+	return c.compare(x, func(a, b float64) bool { return a <= b }, func(a, b string) bool { return a <= b })
+}
+
+// Eq returns bool column which is true where column is equal to other column or scalar
+func (c *Column) Eq(x interface{}) *Column {
+	return c.compare(x, func(a, b float64) bool { return a == b }, func(a, b string) bool { return a == b })
+}
+
+// Ne returns bool column which is true where column is not equal to other column or scalar
+func (c *Column) Ne(x interface{}) *Column {
+	return c.compare(x, func(a, b float64) bool { return a != b }, func(a, b string) bool { return a != b })
+}
+
+/*
+valid returns values which are not NA or NaN
+*/
+func (c *Column) valid() []float64 {
+	a := c.values()
+	r := a[:0]
+	for i, v := range a {
+		if !c.na.Bit(i) && !math.IsNaN(v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+/*
+Sum returns sum of column values, NA values are skipped
+
+	t := tables.New([]struct{Age int; Rate float32}{{32,1.2},{44,1.5}})
+	t.Col("Age").Sum() -> 76
+	t.Col("Age").Mean() -> 38
+	t.Col("Age").Quantile(0.5) -> 38
+*/
+func (c *Column) Sum() float64 {
+	s := 0.0
+	for _, v := range c.valid() {
+		s += v
+	}
+	return s
+}
+
+// Mean returns mean of column values, NA values are skipped, it's NaN for empty column
+func (c *Column) Mean() float64 {
+	a := c.valid()
+	if len(a) == 0 {
+		return math.NaN()
+	}
+	s := 0.0
+	for _, v := range a {
+		s += v
+	}
+	return s / float64(len(a))
+}
+
+// Std returns sample standard deviation of column values, NA values are skipped
+func (c *Column) Std() float64 {
+	a := c.valid()
+	if len(a) < 2 {
+		return math.NaN()
+	}
+	m := 0.0
+	for _, v := range a {
+		m += v
+	}
+	m /= float64(len(a))
+	s := 0.0
+	for _, v := range a {
+		s += (v - m) * (v - m)
+	}
+	return math.Sqrt(s / float64(len(a)-1))
+}
+
+// Quantile returns q-quantile of column values using linear interpolation, NA values are skipped
+func (c *Column) Quantile(q float64) float64 {
+	a := c.valid()
+	if len(a) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(a)
+	p := fu.Maxd(0, fu.Mind(1, q)) * float64(len(a)-1)
+	i := int(p)
+	if i+1 >= len(a) {
+		return a[len(a)-1]
+	}
+	return a[i] + (a[i+1]-a[i])*(p-float64(i))
+}
+
+/*
+Corr returns Pearson correlation of two numeric columns, rows with NA in any column are skipped
+*/
+func (c *Column) Corr(x *Column) float64 {
+	a, b := c.values(), x.values()
+	if len(a) != len(b) {
+		panic(zorros.Panic(zorros.Errorf("column length %d does not match %d", len(b), len(a))))
+	}
+	p, q := make([]float32, 0, len(a)), make([]float32, 0, len(b))
+	for i := range a {
+		if !c.na.Bit(i) && !x.na.Bit(i) && !math.IsNaN(a[i]) && !math.IsNaN(b[i]) {
+			p, q = append(p, float32(a[i])), append(q, float32(b[i]))
+		}
+	}
+	return fu.Corr(p, q)
+}
+
+/*
+ValueCounts returns table with unique values of column and counts of them in columns Value and Count,
+rows are ordered by count descending, NA values are skipped
+
+	t := tables.New([]struct{Name string}{{"Ivanov"},{"Petrov"},{"Petrov"}})
+	t.Col("Name").ValueCounts().Col("Value").Strings() -> {"Petrov","Ivanov"}
+*/
+func (c *Column) ValueCounts() *Table {
+	index := map[interface{}]int{}
+	values := reflect.MakeSlice(c.column.Type(), 0, 0)
+	counts := []int{}
+	for i := 0; i < c.Len(); i++ {
+		if c.na.Bit(i) {
+			continue
+		}
+		v := c.column.Index(i)
+		k := v.Interface()
+		if j, ok := index[k]; ok {
+			counts[j]++
+		} else {
+			index[k] = len(counts)
+			values = reflect.Append(values, v)
+			counts = append(counts, 1)
+		}
+	}
+	order := make([]int, len(counts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	v := reflect.MakeSlice(c.column.Type(), len(order), len(order))
+	n := make([]int, len(order))
+	for i, j := range order {
+		v.Index(i).Set(values.Index(j))
+		n[i] = counts[j]
+	}
+	return MakeTable([]string{"Value", "Count"}, []reflect.Value{v, reflect.ValueOf(n)}, []fu.Bits{{}, {}}, len(n))
+}
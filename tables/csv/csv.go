@@ -9,6 +9,7 @@ import (
 	"go4ml.xyz/zorros"
 	"io"
 	"reflect"
+	"sync"
 )
 
 type Comma rune
@@ -85,6 +86,7 @@ func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
 		}
 
 		rdr.FieldsPerRecord = len(vals)
+		header := vals
 
 		type line struct {
 			vals []string
@@ -94,6 +96,9 @@ func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
 		stopC := make(chan struct{})
 		width := len(names)
 
+		closeOnce := sync.Once{}
+		closeInput := func() { closeOnce.Do(func() { cls.Close() }) }
+
 		go func() {
 			defer close(nC)
 			for {
@@ -101,17 +106,22 @@ func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
 				select {
 				case nC <- line{v, e}:
 				case <-stopC:
-					cls.Close()
+					closeInput()
 					return
 				}
 			}
 		}()
 
 		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
 		return func(index uint64) (reflect.Value, error) {
 			if index == lazy.STOP {
 				wc.Stop()
-				close(stopC)
+				if f.Set() {
+					close(stopC)
+					// closing input unblocks reading goroutine waiting for data
+					closeInput()
+				}
 				return reflect.ValueOf(false), nil
 			}
 			if !wc.Wait(index) {
@@ -119,27 +129,29 @@ func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
 			}
 			l, ok := <-nC
 			wc.Inc()
+			var err error
 			x := reflect.Value{}
 			if ok {
 				if err = l.err; err != nil {
 					if l.err == io.EOF {
 						ok = false
 						err = nil
+					} else if _, bad := l.err.(*csv.ParseError); bad {
+						// reader can continue with the next line
+						return reflect.ValueOf(false), &tables.RowError{Row: RawRow(header, l.vals), Err: err}
 					}
 				} else {
 					output := fu.Struct{names, make([]reflect.Value, width), fu.Bits{}}
 					for i, v := range l.vals {
 						var na bool
 						if na, err = fm[i].Convert(v, &output.Columns[fm[i].field], fm[i].index, fm[i].width); err != nil {
-							break
+							return reflect.ValueOf(false), &tables.RowError{Row: RawRow(header, l.vals), Err: err}
 						}
 						if na {
 							output.Na.Set(fm[i].field, true)
 						}
 					}
-					if err == nil {
-						x = reflect.ValueOf(output)
-					}
+					x = reflect.ValueOf(output)
 				}
 			}
 			if !ok || err != nil {
@@ -151,6 +163,22 @@ func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
 	}
 }
 
+/*
+RawRow returns raw values of bad line as a row of strings, missing values are empty strings,
+it is used to report RowError of text formats like CSV and XLSX
+*/
+func RawRow(header, vals []string) fu.Struct {
+	lr := fu.Struct{Names: header, Columns: make([]reflect.Value, len(header))}
+	for i := range header {
+		v := ""
+		if i < len(vals) {
+			v = vals[i]
+		}
+		lr.Columns[i] = reflect.ValueOf(v)
+	}
+	return lr
+}
+
 /*
 	csv.Write(t,iokit.File("file.csv.xz"),
 				csv.Column("feature_1").Round(2).As("Feature1"))
@@ -0,0 +1,88 @@
+package csv
+
+import (
+	"go4ml.xyz/base/fu"
+	"reflect"
+)
+
+/*
+Fields maps columns of a tabular file to table columns with csv resolvers,
+so other tabular formats accept the same options as csv reader and writer
+
+	fs, err := csv.MapFields(header, opts)
+	lr := fs.Row()
+	for i, s := range values {
+		if err = fs.Convert(i, s, &lr); err != nil {
+			return err
+		}
+	}
+*/
+type Fields struct {
+	Names []string // table columns names
+	fm    []mapper
+}
+
+/*
+MapFields maps file header to table columns with resolvers passed among options
+*/
+func MapFields(header []string, opts []interface{}) (*Fields, error) {
+	fm, names, err := mapFields(header, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Fields{names, fm}, nil
+}
+
+/*
+Row returns new empty table row
+*/
+func (fs *Fields) Row() fu.Struct {
+	return fu.Struct{Names: fs.Names, Columns: make([]reflect.Value, len(fs.Names))}
+}
+
+/*
+Field returns index of table column for i-th file column
+*/
+func (fs *Fields) Field(i int) int {
+	return fs.fm[i].field
+}
+
+/*
+Group returns true if i-th file column is an element of grouped table column
+*/
+func (fs *Fields) Group(i int) bool {
+	return fs.fm[i].group
+}
+
+/*
+Type returns type of table column for i-th file column
+*/
+func (fs *Fields) Type(i int) reflect.Type {
+	return fs.fm[i].Type()
+}
+
+/*
+Convert converts text value of i-th file column and stores it into the table row
+*/
+func (fs *Fields) Convert(i int, value string, lr *fu.Struct) error {
+	m := fs.fm[i]
+	na, err := m.Convert(value, &lr.Columns[m.field], m.index, m.width)
+	if na {
+		lr.Na.Set(m.field, true)
+	}
+	return err
+}
+
+/*
+Format formats value of i-th column of the table row
+*/
+func (fs *Fields) Format(i int, lr fu.Struct) string {
+	return fs.fm[i].Format(lr.Columns[i], lr.Na.Bit(i))
+}
+
+/*
+Formatted returns true if i-th column has custom formatting like Round
+*/
+func (fs *Fields) Formatted(i int) bool {
+	return fs.fm[i].format != nil
+}
@@ -164,7 +164,7 @@ func convertts(s string, layout string, value *reflect.Value) (na bool, err erro
 		*value = fu.TsZero
 		return true, nil
 	}
-	v, err := strconv.ParseFloat(s, 32)
+	v, err := time.Parse(layout, s)
 	*value = reflect.ValueOf(v)
 	return
 }
@@ -0,0 +1,265 @@
+package tables
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/zorros"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Tolerance is the relative tolerance of float values comparison, values less than 1 are compared with absolute tolerance
+type Tolerance float64
+
+// Key is the column matching rows of compared tables, rows are matched by position if no key is specified
+type Key string
+
+/*
+CellDiff is the changed value of row matched in both tables
+*/
+type CellDiff struct {
+	Row    int // row index in the first table
+	Other  int // row index in the second table
+	Column string
+	A, B   interface{} // values, nil for NA
+}
+
+/*
+Difference is the result of tables comparison
+*/
+type Difference struct {
+	Keys    []string
+	Added   []string // columns existing only in the second table
+	Removed []string // columns existing only in the first table
+	Types   []string // columns having incompatible types, their values are not compared
+	Inserts []int    // rows of the second table which are not matched
+	Deletes []int    // rows of the first table which are not matched
+	Changes []CellDiff
+	a, b    *Table
+}
+
+/*
+Equal returns true if tables have the same columns and the same values
+
+	assert.Assert(t, tables.Equal(result, expected, tables.Tolerance(1e-5), tables.Key("Id")))
+
+Options are the same as for Diff.
+*/
+func Equal(a, b *Table, opts ...interface{}) bool {
+	return Diff(a, b, opts...).Empty()
+}
+
+/*
+Diff compares tables and returns difference between them
+
+	d := tables.Diff(result, expected, tables.Tolerance(1e-5), tables.Key("Id"))
+	if !d.Empty() {
+		t.Fatal(d.String())
+	}
+
+Order of columns does not matter. Rows are matched by values of Key columns if Key options are specified,
+otherwise they are matched by position. Key column existing only in one table is reported as removed
+or added column and is not used for matching, Diff panics if key column does not exist in both tables. NA is equal only to NA, NaN is equal to NaN.
+Numeric columns of different types are compared as float64, float values are equal if they differ not more
+than Tolerance multiplied by the greater of their absolute values, or by 1 for small values.
+Tensors are compared by dimensions and values.
+*/
+func Diff(a, b *Table, opts ...interface{}) Difference {
+	tol := fu.Option(Tolerance(0), opts).Float()
+	d := Difference{a: a, b: b}
+	for _, o := range opts {
+		if k, ok := o.(Key); ok {
+			_, ina := a.ColIfExists(string(k))
+			_, inb := b.ColIfExists(string(k))
+			if !ina && !inb {
+				panic(zorros.Panic(zorros.Errorf("key column %v does not exist in compared tables", k)))
+			}
+			// key existing only in one table is reported as removed or added column
+			if ina && inb {
+				d.Keys = append(d.Keys, string(k))
+			}
+		}
+	}
+	common := []string{}
+	for _, n := range a.Names() {
+		c, ok := b.ColIfExists(n)
+		if !ok {
+			d.Removed = append(d.Removed, n)
+		} else if !comparable(a.Col(n).Type(), c.Type()) {
+			d.Types = append(d.Types, n)
+		} else {
+			common = append(common, n)
+		}
+	}
+	for _, n := range b.Names() {
+		if _, ok := a.ColIfExists(n); !ok {
+			d.Added = append(d.Added, n)
+		}
+	}
+	for _, p := range d.match() {
+		if p[0] < 0 {
+			d.Inserts = append(d.Inserts, p[1])
+		} else if p[1] < 0 {
+			d.Deletes = append(d.Deletes, p[0])
+		} else {
+			for _, n := range common {
+				ca, cb := a.Col(n), b.Col(n)
+				if !equalCells(ca.Index(p[0]).Value, ca.Na(p[0]), cb.Index(p[1]).Value, cb.Na(p[1]), tol) {
+					d.Changes = append(d.Changes, CellDiff{p[0], p[1], n, cellValue(ca, p[0]), cellValue(cb, p[1])})
+				}
+			}
+		}
+	}
+	return d
+}
+
+/*
+match returns pairs of matched rows, the index is -1 if row does not have pair
+*/
+func (d Difference) match() (r [][2]int) {
+	if len(d.Keys) == 0 {
+		for i := 0; i < fu.Maxi(d.a.Len(), d.b.Len()); i++ {
+			r = append(r, [2]int{fu.Ifei(i < d.a.Len(), i, -1), fu.Ifei(i < d.b.Len(), i, -1)})
+		}
+		return
+	}
+	// rows having the same key are matched in order of appearance
+	index := map[string][]int{}
+	for j := 0; j < d.b.Len(); j++ {
+		k := d.key(d.b, j)
+		index[k] = append(index[k], j)
+	}
+	matched := make([]bool, d.b.Len())
+	for i := 0; i < d.a.Len(); i++ {
+		k := d.key(d.a, i)
+		if q := index[k]; len(q) > 0 {
+			r = append(r, [2]int{i, q[0]})
+			matched[q[0]] = true
+			index[k] = q[1:]
+		} else {
+			r = append(r, [2]int{i, -1})
+		}
+	}
+	for j, ok := range matched {
+		if !ok {
+			r = append(r, [2]int{-1, j})
+		}
+	}
+	return
+}
+
+func (d Difference) key(t *Table, i int) string {
+	s := make([]string, len(d.Keys))
+	for j, k := range d.Keys {
+		c := t.Col(k)
+		s[j] = fmt.Sprint(fu.Ife(c.Na(i), "N/A", c.Index(i).Interface()))
+	}
+	return strings.Join(s, "\x00")
+}
+
+// Empty returns true if tables are equal
+func (d Difference) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Types)+len(d.Inserts)+len(d.Deletes)+len(d.Changes) == 0
+}
+
+/*
+String returns human readable report of difference
+
+	fmt.Println(tables.Diff(a, b, tables.Key("Id")))
+	// - column Comment
+	// + column Score
+	// ~ column Age: int => string
+	// - row 3: fu.Struct{Id:4, Rate:1.5}
+	// + row 2: fu.Struct{Id:5, Rate:2}
+	// ~ row 0 (Id=1): Rate: 1.2 => 1.25
+*/
+func (d Difference) String() string {
+	if d.Empty() {
+		return "tables are equal"
+	}
+	r := []string{}
+	for _, n := range d.Removed {
+		r = append(r, "- column "+n)
+	}
+	for _, n := range d.Added {
+		r = append(r, "+ column "+n)
+	}
+	for _, n := range d.Types {
+		r = append(r, fmt.Sprintf("~ column %v: %v => %v", n, d.a.Col(n).Type(), d.b.Col(n).Type()))
+	}
+	for _, i := range d.Deletes {
+		r = append(r, fmt.Sprintf("- row %d: %v", i, d.a.Index(i)))
+	}
+	for _, j := range d.Inserts {
+		r = append(r, fmt.Sprintf("+ row %d: %v", j, d.b.Index(j)))
+	}
+	for _, c := range d.Changes {
+		row := fmt.Sprintf("row %d", c.Row)
+		if len(d.Keys) > 0 {
+			k := make([]string, len(d.Keys))
+			for i, n := range d.Keys {
+				k[i] = fmt.Sprintf("%v=%v", n, fu.Ife(d.a.Col(n).Na(c.Row), "N/A", d.a.Col(n).Index(c.Row).Interface()))
+			}
+			row += " (" + strings.Join(k, ", ") + ")"
+		} else if c.Other != c.Row {
+			row += fmt.Sprintf("/%d", c.Other)
+		}
+		r = append(r, fmt.Sprintf("~ %v: %v: %v => %v", row, c.Column, naText(c.A), naText(c.B)))
+	}
+	return strings.Join(r, "\n")
+}
+
+func naText(v interface{}) interface{} {
+	if v == nil {
+		return "N/A"
+	}
+	return v
+}
+
+func cellValue(c *Column, i int) interface{} {
+	if c.Na(i) {
+		return nil
+	}
+	return c.Index(i).Interface()
+}
+
+func numeric(t reflect.Type) bool {
+	return isInt(t) || isUint(t) || isFloat(t) || t == fu.Fixed8Type
+}
+
+func comparable(a, b reflect.Type) bool {
+	return a == b || numeric(a) && numeric(b)
+}
+
+func equalCells(a reflect.Value, naa bool, b reflect.Value, nab bool, tol float64) bool {
+	if naa || nab {
+		return naa == nab
+	}
+	if numeric(a.Type()) && (isFloat(a.Type()) || isFloat(b.Type()) || a.Type() != b.Type()) {
+		return equalFloats(fu.Convert(a, false, fu.Float64).Float(), fu.Convert(b, false, fu.Float64).Float(), tol)
+	}
+	if x, ok := a.Interface().(fu.Tensor); ok {
+		y := b.Interface().(fu.Tensor)
+		c1, h1, w1 := x.Dimension()
+		c2, h2, w2 := y.Dimension()
+		if c1 != c2 || h1 != h2 || w1 != w2 {
+			return false
+		}
+		p, q := x.Floats32(), y.Floats32()
+		for i := range p {
+			if !equalFloats(float64(p[i]), float64(q[i]), tol) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func equalFloats(a, b, tol float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return a == b || math.Abs(a-b) <= tol*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
@@ -0,0 +1,141 @@
+package tables
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/zorros"
+	"golang.org/x/xerrors"
+	"reflect"
+	"sync"
+)
+
+/*
+RowError is an error of one row, the stream returned it can continue with the next row,
+so OnError policy can skip the row instead of failing the pipeline
+*/
+type RowError struct {
+	Row fu.Struct // the bad row or its raw values, can be empty
+	Err error
+}
+
+func (e *RowError) Error() string { return e.Err.Error() }
+func (e *RowError) Unwrap() error { return e.Err }
+
+/*
+ErrorPolicy defines how pipeline handles row errors, the zero policy fails on the first error
+*/
+type ErrorPolicy struct {
+	Skip       bool // skips bad rows and counts them
+	DeadLetter Sink // receives bad rows as Index, Error and Row columns, bad rows are skipped
+	MaxErrors  int  // fails pipeline when there are more than MaxErrors bad rows, 0 means no limit
+}
+
+// maxErrorSamples is the count of errors ErrorSummary keeps
+const maxErrorSamples = 10
+
+/*
+ErrorSummary is a summary of rows skipped by OnError policy
+*/
+type ErrorSummary struct {
+	Skipped int         // count of skipped rows
+	Samples []*RowError // the first skipped rows errors
+	Err     error       // error of dead-letter sink commit
+	mu      sync.Mutex
+}
+
+/*
+OnError applies error policy to row errors of the upstream,
+errors which are not RowError always fail pipeline
+
+	summary := &tables.ErrorSummary{}
+	err := csv.Source(iokit.File("dataset.csv"), csv.Float32("x").As("X")).
+		Transform(validate).
+		OnError(tables.ErrorPolicy{
+			DeadLetter: csv.Sink(iokit.File("bad.csv")),
+			MaxErrors:  100},
+			summary).
+		Drain(csv.Sink(iokit.File("good.csv")))
+	fmt.Println(summary.Skipped)
+
+Dead-letter rows are written in order of upstream indices, also when the stream is consumed by Parallel.
+Dead-letter sink is committed when the stream is stopped, so after all rows are passed downstream, it gets
+true end-marker if OnError did not fail the pipeline, including when downstream stops early like First does,
+and false otherwise. The error of dead-letter commit is reported in ErrorSummary.Err.
+*/
+func (zf Lazy) OnError(policy ErrorPolicy, summary ...*ErrorSummary) Lazy {
+	if !policy.Skip && policy.DeadLetter == nil {
+		return zf
+	}
+	return func() lazy.Stream {
+		z := zf()
+		s := &ErrorSummary{}
+		if len(summary) > 0 && summary[0] != nil {
+			s = summary[0]
+		}
+		wc := fu.WaitCounter{Value: 0}
+		failed := fu.AtomicFlag{Value: 0}
+		mu := sync.Mutex{}
+		stopped := false // dead-letter sink is committed or rolled back
+		return func(index uint64) (v reflect.Value, err error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				mu.Lock()
+				if !stopped && policy.DeadLetter != nil {
+					s.Err = policy.DeadLetter(reflect.ValueOf(!failed.State()))
+				}
+				stopped = true
+				mu.Unlock()
+				return z(index)
+			}
+			v, err = z(index)
+			if !wc.Wait(index) {
+				return fu.False, nil
+			}
+			defer wc.Inc()
+			if err == nil {
+				return
+			}
+			re := (*RowError)(nil)
+			if !xerrors.As(err, &re) {
+				failed.Set()
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if stopped {
+				// the row is requested ahead by Parallel and is not passed downstream
+				return fu.False, nil
+			}
+			if err = s.add(index, re, policy); err != nil {
+				failed.Set()
+				return fu.False, err
+			}
+			return fu.True, nil
+		}
+	}
+}
+
+func (s *ErrorSummary) add(index uint64, re *RowError, policy ErrorPolicy) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Skipped++
+	if len(s.Samples) < maxErrorSamples {
+		s.Samples = append(s.Samples, re)
+	}
+	if policy.MaxErrors > 0 && s.Skipped > policy.MaxErrors {
+		return zorros.Errorf("there are more than %d bad rows, the last error at row %d: %v", policy.MaxErrors, index, re.Err)
+	}
+	if policy.DeadLetter != nil {
+		row := ""
+		if len(re.Row.Names) > 0 {
+			row = re.Row.String()
+		}
+		lr := fu.Struct{
+			Names:   []string{"Index", "Error", "Row"},
+			Columns: []reflect.Value{reflect.ValueOf(int(index)), reflect.ValueOf(re.Err.Error()), reflect.ValueOf(row)}}
+		if err = policy.DeadLetter(reflect.ValueOf(lr)); err != nil {
+			return zorros.Wrapf(err, "failed to write dead-letter row: %s", err.Error())
+		}
+	}
+	return
+}
@@ -0,0 +1,268 @@
+package files
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+/*
+Reader creates lazy source reading one file, csv.Source, xlsx.Source and libsvm.Source are readers
+*/
+type Reader func(source interface{}, opts ...interface{}) tables.Lazy
+
+// Provenance adds column with the source file name
+type Provenance string
+
+// Parallel reads up to the specified count of files concurrently, rows order is preserved
+type Parallel int
+
+// rowsBuffer is the count of rows read ahead from one file
+const rowsBuffer = 64
+
+/*
+Read reads all files with the format reader into one table
+
+	// reads all shards with csv reader adding column File with the name of shard
+	files.Read(csv.Source, "data/part-*.csv",
+		csv.Float32("feature_1").As("Feature1"),
+		csv.Int("label").As("Label"),
+		files.Provenance("File"),
+		files.Parallel(4))
+
+	// reads compressed shards
+	gz := func(s interface{}, opts ...interface{}) tables.Lazy {
+		return csv.Source(iokit.Compressed(s.(iokit.Input)), opts...)
+	}
+	files.Read(gz, "data/part-*.csv.gz")
+
+Sources is a glob pattern, a list of file names or a list of iokit.Input.
+All options are passed to the format reader as well.
+Files have to have the same columns, the order of columns can differ from file to file,
+and the same types of columns. Errors are reported with the name of file they occurred in.
+*/
+func Read(reader Reader, sources interface{}, opts ...interface{}) (*tables.Table, error) {
+	return Source(reader, sources, opts...).Collect()
+}
+
+func Source(reader Reader, sources interface{}, opts ...interface{}) tables.Lazy {
+	inputs, names, err := resolve(sources)
+	if err != nil {
+		return tables.SourceError(err)
+	}
+	return func() lazy.Stream {
+		type item struct {
+			lr  fu.Struct
+			err error
+		}
+		stop := make(chan struct{})
+		sem := make(chan struct{}, fu.Maxi(1, fu.IntOption(Parallel(1), opts)))
+		chans := make([]chan item, len(inputs))
+		for i := range chans {
+			chans[i] = make(chan item, rowsBuffer)
+		}
+		wg := sync.WaitGroup{}
+
+		read := func(k int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer close(chans[k])
+			z := reader(inputs[k], opts...)()
+			defer z(lazy.STOP)
+			for i := uint64(0); ; i++ {
+				v, err := z(i)
+				if err == nil && v.Kind() == reflect.Bool {
+					if v.Bool() {
+						continue
+					}
+					return
+				}
+				it := item{err: err}
+				re, rowError := err.(*tables.RowError)
+				if rowError {
+					it.err = &tables.RowError{Row: re.Row, Err: zorros.Wrapf(re.Err, "file %v: %s", names[k], re.Err.Error())}
+				} else if err != nil {
+					it.err = zorros.Wrapf(err, "file %v: %s", names[k], err.Error())
+				} else {
+					it.lr = v.Interface().(fu.Struct)
+				}
+				select {
+				case chans[k] <- it:
+				case <-stop:
+					return
+				}
+				if err != nil && !rowError {
+					return
+				}
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range inputs {
+				select {
+				case sem <- struct{}{}:
+				case <-stop:
+					return
+				}
+				wg.Add(1)
+				go read(k)
+			}
+		}()
+
+		f := fu.AtomicFlag{Value: 0}
+		finish := func() {
+			if f.Set() {
+				close(stop)
+				wg.Wait()
+			}
+		}
+
+		sch := schema{}
+		provenance := fu.StrOption(Provenance(""), opts)
+		wc := fu.WaitCounter{Value: 0}
+		k := 0
+		return func(index uint64) (reflect.Value, error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				finish()
+				return reflect.ValueOf(false), nil
+			}
+			if !wc.Wait(index) {
+				return reflect.ValueOf(false), nil
+			}
+			for k < len(chans) {
+				it, ok := <-chans[k]
+				if !ok {
+					k++
+					sch.next()
+					continue
+				}
+				if _, ok := it.err.(*tables.RowError); ok {
+					wc.Inc()
+					return reflect.ValueOf(false), it.err
+				}
+				lr, err := it.lr, it.err
+				if err == nil {
+					lr, err = sch.conform(lr, names[k])
+				}
+				if err != nil {
+					wc.Stop()
+					finish()
+					return reflect.ValueOf(false), err
+				}
+				if provenance != "" {
+					lr = lr.Set(provenance, reflect.ValueOf(names[k]))
+				}
+				wc.Inc()
+				return reflect.ValueOf(lr), nil
+			}
+			wc.Stop()
+			finish()
+			return reflect.ValueOf(false), nil
+		}
+	}
+}
+
+/*
+resolve converts sources into list of inputs and their names
+*/
+func resolve(sources interface{}) (inputs []iokit.Input, names []string, err error) {
+	switch x := sources.(type) {
+	case string:
+		var matches []string
+		if matches, err = filepath.Glob(x); err != nil {
+			return nil, nil, zorros.Wrapf(err, "bad glob pattern %v: %s", x, err.Error())
+		}
+		if len(matches) == 0 {
+			return nil, nil, zorros.Errorf("there are no files matching %v", x)
+		}
+		return resolve(matches)
+	case []string:
+		for _, n := range x {
+			inputs = append(inputs, iokit.File(n))
+			names = append(names, n)
+		}
+	case []iokit.Input:
+		for i, n := range x {
+			inputs = append(inputs, n)
+			names = append(names, inputName(i, n))
+		}
+	default:
+		return nil, nil, zorros.Errorf("files source does not know sources type %v", reflect.TypeOf(sources).String())
+	}
+	return
+}
+
+func inputName(i int, input iokit.Input) string {
+	if s, ok := input.(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v := reflect.ValueOf(input); v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+/*
+schema is the columns set of the first file, rows of other files are conformed to it
+*/
+type schema struct {
+	names   []string
+	types   []reflect.Type
+	order   []int // order of columns in rows of the current file
+	checked bool  // columns of the current file are already checked by its first row
+}
+
+func (s *schema) next() {
+	s.checked = false
+	s.order = nil
+}
+
+func (s *schema) conform(lr fu.Struct, file string) (fu.Struct, error) {
+	if s.names == nil {
+		s.names = lr.Names
+		s.types = make([]reflect.Type, len(lr.Names))
+		s.checked = true
+	}
+	if !s.checked {
+		s.checked = true
+		if !reflect.DeepEqual(lr.Names, s.names) {
+			if len(lr.Names) != len(s.names) {
+				return lr, zorros.Errorf("file %v: columns %v do not match columns %v", file, lr.Names, s.names)
+			}
+			s.order = make([]int, len(s.names))
+			for i, n := range s.names {
+				if s.order[i] = lr.Pos(n); s.order[i] < 0 {
+					return lr, zorros.Errorf("file %v: columns %v do not match columns %v", file, lr.Names, s.names)
+				}
+			}
+		}
+	}
+	if s.order != nil {
+		r := fu.Struct{Names: s.names, Columns: make([]reflect.Value, len(s.names))}
+		for i, j := range s.order {
+			r.Columns[i] = lr.Columns[j]
+			r.Na.Set(i, lr.Na.Bit(j))
+		}
+		lr = r
+	}
+	for i, c := range lr.Columns {
+		if lr.Na.Bit(i) || !c.IsValid() {
+			continue
+		}
+		if s.types[i] == nil {
+			s.types[i] = c.Type()
+		} else if s.types[i] != c.Type() {
+			return lr, zorros.Errorf("file %v: column %v has type %v instead of %v", file, s.names[i], c.Type(), s.types[i])
+		}
+	}
+	return lr, nil
+}
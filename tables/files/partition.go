@@ -0,0 +1,296 @@
+package files
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Writer creates sink writing one file, csv.Sink, xlsx.Sink and libsvm.Sink are writers
+*/
+type Writer func(dest iokit.Output, opts ...interface{}) tables.Sink
+
+// MaxOpen limits the count of simultaneously written files, 16 by default
+type MaxOpen int
+
+// MaxRows rolls file over after the specified count of rows
+type MaxRows int
+
+// Ext is the extension of written files, .csv by default
+type Ext string
+
+// DefaultPartition is the name of partition for NA values
+const DefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+/*
+Write writes table into directory partitioned by values of columns
+
+	// writes files like out/date=2026-10-18/part-0.csv
+	files.Write(t, csv.Sink, "out", []string{"date"}, files.MaxRows(100000))
+*/
+func Write(t *tables.Table, writer Writer, root string, partition []string, opts ...interface{}) error {
+	return t.Lazy().Drain(Sink(writer, root, partition, opts...))
+}
+
+/*
+Sink creates sink writing rows into Hive-style partitioned directory
+root/column1=value1/column2=value2/part-N.ext, partition columns are not written into files.
+
+Files are created lazily on the first row of partition in temporary directory
+and moved to the final place only when the stream ends successfully.
+Sink fails without moving any file if one of final files already exists,
+and files already moved are moved back if another file can't be moved.
+Part files are numbered after part files already existing in partition directory,
+so writing into the same root again adds files instead of replacing them.
+If there are more than MaxOpen partitions written at the same time,
+the least recently used file is finished and the next rows of its partition go to the next part file.
+The same happens after MaxRows rows written into one file.
+*/
+func Sink(writer Writer, root string, partition []string, opts ...interface{}) tables.Sink {
+	pw := &partitionedWriter{
+		writer:    writer,
+		root:      root,
+		partition: partition,
+		maxOpen:   fu.Maxi(1, fu.IntOption(MaxOpen(16), opts)),
+		maxRows:   fu.IntOption(MaxRows(0), opts),
+		ext:       fu.StrOption(Ext(".csv"), opts),
+		opts:      opts,
+		open:      map[string]*partFile{},
+		parts:     map[string]int{},
+	}
+	return func(v reflect.Value) error {
+		if v.Kind() == reflect.Bool {
+			return pw.end(v.Bool())
+		}
+		return pw.write(v.Interface().(fu.Struct))
+	}
+}
+
+type partFile struct {
+	sink           tables.Sink
+	staged, final  string
+	rows, lastUsed int
+}
+
+type partitionedWriter struct {
+	writer           Writer
+	root, staging    string
+	partition        []string
+	maxOpen, maxRows int
+	ext              string
+	opts             []interface{}
+	open             map[string]*partFile
+	parts            map[string]int // number of the next part file of partition
+	finished         []*partFile
+	columns          []int // positions of partition columns in row
+	names            []string
+	rest             []int // positions of written columns in row
+	counter          int
+}
+
+func (pw *partitionedWriter) write(lr fu.Struct) (err error) {
+	if pw.columns == nil {
+		pw.columns = make([]int, len(pw.partition))
+		for i, n := range pw.partition {
+			if pw.columns[i] = lr.Pos(n); pw.columns[i] < 0 {
+				return zorros.Errorf("partition column %v does not exist", n)
+			}
+		}
+		for i, n := range lr.Names {
+			if fu.IndexOf(n, pw.partition) < 0 {
+				pw.rest = append(pw.rest, i)
+				pw.names = append(pw.names, n)
+			}
+		}
+	}
+	dirs := make([]string, len(pw.columns))
+	for i, j := range pw.columns {
+		dirs[i] = pw.partition[i] + "=" + partitionValue(lr.Columns[j], lr.Na.Bit(j))
+	}
+	dir := filepath.Join(dirs...)
+	pf, ok := pw.open[dir]
+	if !ok {
+		if pf, err = pw.create(dir); err != nil {
+			return
+		}
+	}
+	pw.counter++
+	pf.lastUsed = pw.counter
+	r := fu.Struct{Names: pw.names, Columns: make([]reflect.Value, len(pw.rest))}
+	for i, j := range pw.rest {
+		r.Columns[i] = lr.Columns[j]
+		r.Na.Set(i, lr.Na.Bit(j))
+	}
+	if err = pf.sink(reflect.ValueOf(r)); err != nil {
+		return
+	}
+	if pf.rows++; pw.maxRows > 0 && pf.rows >= pw.maxRows {
+		return pw.finish(dir)
+	}
+	return
+}
+
+func (pw *partitionedWriter) create(dir string) (pf *partFile, err error) {
+	if len(pw.open) >= pw.maxOpen {
+		lru := ""
+		for k, x := range pw.open {
+			if lru == "" || x.lastUsed < pw.open[lru].lastUsed {
+				lru = k
+			}
+		}
+		if err = pw.finish(lru); err != nil {
+			return
+		}
+	}
+	if pw.staging == "" {
+		// staging directory is created on the first row, so unused sink leaves nothing
+		if err = os.MkdirAll(pw.root, 0755); err != nil {
+			return nil, zorros.Trace(err)
+		}
+		if pw.staging, err = ioutil.TempDir(pw.root, "_temporary"); err != nil {
+			return nil, zorros.Trace(err)
+		}
+	}
+	if _, ok := pw.parts[dir]; !ok {
+		pw.parts[dir] = nextPart(filepath.Join(pw.root, dir))
+	}
+	name := fmt.Sprintf("part-%d%s", pw.parts[dir], pw.ext)
+	pw.parts[dir]++
+	pf = &partFile{
+		staged: filepath.Join(pw.staging, dir, name),
+		final:  filepath.Join(pw.root, dir, name),
+	}
+	if err = os.MkdirAll(filepath.Dir(pf.staged), 0755); err != nil {
+		return nil, zorros.Trace(err)
+	}
+	pf.sink = pw.writer(iokit.File(pf.staged), pw.opts...)
+	pw.open[dir] = pf
+	return
+}
+
+/*
+nextPart returns number following the greatest number of part files existing in directory
+*/
+func nextPart(dir string) int {
+	n := 0
+	fs, _ := ioutil.ReadDir(dir)
+	for _, f := range fs {
+		s := f.Name()
+		if !strings.HasPrefix(s, "part-") {
+			continue
+		}
+		s = s[len("part-"):]
+		if i := strings.IndexByte(s, '.'); i >= 0 {
+			s = s[:i]
+		}
+		if k, err := strconv.Atoi(s); err == nil && k >= n {
+			n = k + 1
+		}
+	}
+	return n
+}
+
+/*
+finish completes the file of partition, so it's ready to be moved to the final place
+*/
+func (pw *partitionedWriter) finish(dir string) error {
+	pf := pw.open[dir]
+	delete(pw.open, dir)
+	if err := pf.sink(reflect.ValueOf(true)); err != nil {
+		return zorros.Wrapf(err, "failed to write %v: %s", pf.final, err.Error())
+	}
+	pw.finished = append(pw.finished, pf)
+	return nil
+}
+
+func (pw *partitionedWriter) end(success bool) (err error) {
+	keep := false
+	defer func() {
+		if pw.staging != "" && !keep {
+			os.RemoveAll(pw.staging)
+		}
+	}()
+	dirs := make([]string, 0, len(pw.open))
+	for k := range pw.open {
+		dirs = append(dirs, k)
+	}
+	sort.Strings(dirs)
+	for _, k := range dirs {
+		if !success || err != nil {
+			_ = pw.open[k].sink(reflect.ValueOf(false))
+			delete(pw.open, k)
+		} else {
+			err = pw.finish(k)
+		}
+	}
+	if !success || err != nil {
+		return
+	}
+	// conflicts are checked before the first rename, so nothing is moved if any file exists
+	for _, pf := range pw.finished {
+		// rename replaces existing file silently
+		if _, e := os.Stat(pf.final); e == nil {
+			return zorros.Errorf("file %v already exists", pf.final)
+		}
+	}
+	for i, pf := range pw.finished {
+		if err = os.MkdirAll(filepath.Dir(pf.final), 0755); err == nil {
+			err = os.Rename(pf.staged, pf.final)
+		}
+		if err != nil {
+			err = zorros.Wrapf(err, "failed to move partition file %v: %s", pf.final, err.Error())
+			// already moved files are moved back, staging directory is kept if they can't be moved
+			for _, x := range pw.finished[:i] {
+				if e := os.Rename(x.final, x.staged); e != nil {
+					keep = true
+				}
+			}
+			return
+		}
+	}
+	return
+}
+
+/*
+partitionValue formats value as a directory name escaping special characters in the Hive way
+*/
+func partitionValue(v reflect.Value, na bool) string {
+	if na || !v.IsValid() {
+		return DefaultPartition
+	}
+	s := ""
+	switch x := v.Interface().(type) {
+	case time.Time:
+		// Truncate works in UTC, so midnight is checked in the time zone of value
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+			s = x.Format("2006-01-02")
+		} else {
+			s = x.Format(time.RFC3339)
+		}
+	default:
+		s = fmt.Sprint(x)
+	}
+	if s == "" {
+		return DefaultPartition
+	}
+	b := strings.Builder{}
+	for _, c := range []byte(s) {
+		if c < ' ' || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package images
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/zorros"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var extensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+type imageFile struct {
+	path  string
+	label int
+}
+
+/*
+listFolder lists images in sub-directories of root ordered by sub-directory and file name,
+labels are enumerated by enumset, the enumset is extended if it's empty or has to contain all labels otherwise
+*/
+func listFolder(root string, enumset tables.Enumset) (files []imageFile, err error) {
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		return
+	}
+	readonly := len(enumset) != 0
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		label, ok := enumset[d.Name()]
+		if !ok {
+			if readonly {
+				return nil, zorros.Errorf("enumset does not have label `%v`", d.Name())
+			}
+			label = len(enumset)
+			enumset[d.Name()] = label
+		}
+		var fs []os.FileInfo
+		if fs, err = ioutil.ReadDir(filepath.Join(root, d.Name())); err != nil {
+			return
+		}
+		for _, f := range fs {
+			if !f.IsDir() && extensions[strings.ToLower(filepath.Ext(f.Name()))] {
+				files = append(files, imageFile{filepath.Join(root, d.Name(), f.Name()), label})
+			}
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return
+}
+
+func decodeFile(path string) (p picture, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return p, zorros.Wrapf(err, "failed to decode image %v: %s", path, err.Error())
+	}
+	return fromImage(img), nil
+}
+
+/*
+ReadFolder reads PNG, JPEG and GIF images from sub-directories of root into table
+with image tensor column and int label column enumerating sub-directory names
+
+	labels := tables.Enumset{}
+	images.ReadFolder("dataset/train", labels,
+		images.Resize{Width:64, Height:64},
+		images.Channels(3),
+		images.Layout(images.HWC))
+
+The labels enumset is filled with sub-directory names if it's empty,
+otherwise it has to contain all sub-directory names. Grayscale images have one channel,
+images with transparency have four channels and others have three channels
+unless images.Channels option is used.
+*/
+func ReadFolder(root string, labels tables.Enumset, opts ...interface{}) (*tables.Table, error) {
+	return FolderSource(root, labels, opts...).Collect()
+}
+
+func FolderSource(root string, labels tables.Enumset, opts ...interface{}) tables.Lazy {
+	return func() lazy.Stream {
+		if labels == nil {
+			labels = tables.Enumset{}
+		}
+		files, err := listFolder(root, labels)
+		if err != nil {
+			return lazy.Error(err)
+		}
+		names := []string{fu.StrOption(Image("Image"), opts), fu.StrOption(Label("Label"), opts)}
+		flag := &fu.AtomicFlag{Value: 1}
+		return func(index uint64) (reflect.Value, error) {
+			if index == lazy.STOP {
+				flag.Clear()
+			} else if flag.State() && index < uint64(len(files)) {
+				p, err := decodeFile(files[index].path)
+				if err != nil {
+					flag.Clear()
+					return reflect.ValueOf(false), err
+				}
+				return reflect.ValueOf(row(names, transform(p, opts), reflect.ValueOf(files[index].label))), nil
+			}
+			return reflect.ValueOf(false), nil
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package images
+
+import (
+	"bufio"
+	"encoding/binary"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"io"
+	"math"
+	"reflect"
+)
+
+const (
+	idxUbyte  = 0x08
+	idxSbyte  = 0x09
+	idxShort  = 0x0b
+	idxInt    = 0x0c
+	idxFloat  = 0x0d
+	idxDouble = 0x0e
+)
+
+/*
+idxFile is an opened IDX file, count is the first dimension and dims are dimensions of one record
+*/
+type idxFile struct {
+	io.ReadCloser
+	rd    *bufio.Reader
+	tp    byte
+	count int
+	dims  []int
+	buf   []byte
+}
+
+func openIdx(source iokit.Input) (f *idxFile, err error) {
+	rd, err := source.Open()
+	if err != nil {
+		return
+	}
+	f = &idxFile{ReadCloser: rd, rd: bufio.NewReader(rd)}
+	var magic [4]byte
+	if _, err = io.ReadFull(f.rd, magic[:]); err != nil {
+		rd.Close()
+		return nil, zorros.Wrapf(err, "failed to read IDX header: %s", err.Error())
+	}
+	if magic[0] != 0 || magic[1] != 0 || magic[3] == 0 || idxSize(magic[2]) == 0 {
+		rd.Close()
+		return nil, zorros.Errorf("it's not an IDX file")
+	}
+	f.tp = magic[2]
+	dims := make([]uint32, magic[3])
+	if err = binary.Read(f.rd, binary.BigEndian, dims); err != nil {
+		rd.Close()
+		return nil, zorros.Wrapf(err, "failed to read IDX header: %s", err.Error())
+	}
+	f.count = int(dims[0])
+	volume := 1
+	for _, d := range dims[1:] {
+		f.dims = append(f.dims, int(d))
+		volume *= int(d)
+	}
+	f.buf = make([]byte, volume*idxSize(f.tp))
+	return
+}
+
+func idxSize(tp byte) int {
+	switch tp {
+	case idxUbyte, idxSbyte:
+		return 1
+	case idxShort:
+		return 2
+	case idxInt, idxFloat:
+		return 4
+	case idxDouble:
+		return 8
+	}
+	return 0
+}
+
+/*
+next reads the next record as float32 values
+*/
+func (f *idxFile) next() ([]float32, error) {
+	if _, err := io.ReadFull(f.rd, f.buf); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			err = zorros.Errorf("IDX file is truncated")
+		}
+		return nil, err
+	}
+	r := make([]float32, len(f.buf)/idxSize(f.tp))
+	for i := range r {
+		switch f.tp {
+		case idxUbyte:
+			r[i] = float32(f.buf[i])
+		case idxSbyte:
+			r[i] = float32(int8(f.buf[i]))
+		case idxShort:
+			r[i] = float32(int16(binary.BigEndian.Uint16(f.buf[i*2:])))
+		case idxInt:
+			r[i] = float32(int32(binary.BigEndian.Uint32(f.buf[i*4:])))
+		case idxFloat:
+			r[i] = math.Float32frombits(binary.BigEndian.Uint32(f.buf[i*4:]))
+		case idxDouble:
+			r[i] = float32(math.Float64frombits(binary.BigEndian.Uint64(f.buf[i*8:])))
+		}
+	}
+	return r, nil
+}
+
+/*
+picture converts record of images file into picture,
+records are (width), (height,width) or (height,width,channels) with interleaved channels
+*/
+func (f *idxFile) picture(v []float32) (p picture, err error) {
+	p = picture{1, 1, 1, v, f.tp == idxUbyte}
+	switch len(f.dims) {
+	case 1:
+		p.width = f.dims[0]
+	case 2:
+		p.height, p.width = f.dims[0], f.dims[1]
+	case 3:
+		p.height, p.width, p.channels = f.dims[0], f.dims[1], f.dims[2]
+		plane := p.height * p.width
+		p.pix = make([]float32, len(v))
+		for j, x := range v {
+			p.pix[j%p.channels*plane+j/p.channels] = x
+		}
+	default:
+		err = zorros.Errorf("IDX images file has unsupported dimensions %v", f.dims)
+	}
+	return
+}
+
+/*
+ReadIdx reads images and optional labels in IDX format into table with image tensor column and int label column
+
+	images.ReadIdx(
+		iokit.Compressed(iokit.File("train-images-idx3-ubyte.gz")),
+		iokit.Compressed(iokit.File("train-labels-idx1-ubyte.gz")))
+
+	images.ReadIdx(iokit.File("t10k-images-idx3-ubyte"), nil,
+		images.Float32(true),
+		images.Resize{Width:32, Height:32},
+		images.Image("Digit"))
+
+Images of unsigned bytes produce byte tensors unless images.Float32 option is used,
+images of other types always produce float32 tensors.
+*/
+func ReadIdx(images iokit.Input, labels iokit.Input, opts ...interface{}) (*tables.Table, error) {
+	return IdxSource(images, labels, opts...).Collect()
+}
+
+func IdxSource(images iokit.Input, labels iokit.Input, opts ...interface{}) tables.Lazy {
+	return func() lazy.Stream {
+		imf, err := openIdx(images)
+		if err != nil {
+			return lazy.Error(err)
+		}
+		names := []string{fu.StrOption(Image("Image"), opts)}
+		var lbf *idxFile
+		if labels != nil {
+			if lbf, err = openIdx(labels); err != nil {
+				imf.Close()
+				return lazy.Error(err)
+			}
+			if len(lbf.dims) != 0 || lbf.count != imf.count {
+				imf.Close()
+				lbf.Close()
+				return lazy.Error(zorros.Errorf("IDX labels file does not match images file"))
+			}
+			names = append(names, fu.StrOption(Label("Label"), opts))
+		}
+		closeAll := func() {
+			imf.Close()
+			if lbf != nil {
+				lbf.Close()
+			}
+		}
+
+		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
+		return func(index uint64) (reflect.Value, error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				if f.Set() {
+					closeAll()
+				}
+				return reflect.ValueOf(false), nil
+			}
+			if !wc.Wait(index) {
+				return reflect.ValueOf(false), nil
+			}
+			err := io.EOF
+			if index < uint64(imf.count) {
+				var v []float32
+				var p picture
+				if v, err = imf.next(); err == nil {
+					p, err = imf.picture(v)
+				}
+				label := reflect.Value{}
+				if err == nil && lbf != nil {
+					if v, err = lbf.next(); err == nil {
+						label = reflect.ValueOf(int(v[0]))
+					}
+				}
+				if err == nil {
+					wc.Inc()
+					return reflect.ValueOf(row(names, transform(p, opts), label)), nil
+				}
+			}
+			wc.Stop()
+			if f.Set() {
+				closeAll()
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			return reflect.ValueOf(false), err
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package images
+
+import (
+	"go4ml.xyz/base/fu"
+	"image"
+	"image/color"
+	"math"
+	"reflect"
+)
+
+// Image is the name of image column, Image by default
+type Image string
+
+// Label is the name of label column, Label by default
+type Label string
+
+// Resize scales images to the specified size with bilinear interpolation
+type Resize struct{ Width, Height int }
+
+// Channels converts images to 1 (grayscale), 3 (RGB) or 4 (RGBA) channels
+type Channels int
+
+// Float32 produces float32 tensors with pixels scaled into [0,1] instead of byte tensors
+type Float32 bool
+
+// Layout is the order of pixel values in tensor
+type Layout int
+
+const (
+	// CHW stores channels one after another, it's the default
+	CHW Layout = iota
+	// HWC interleaves channels of every pixel, tensor dimension is still (channels,height,width)
+	HWC
+)
+
+/*
+picture is a decoded image with channels stored one after another,
+values are in [0,255] if picture is decoded from bytes
+*/
+type picture struct {
+	channels, height, width int
+	pix                     []float32
+	bytes                   bool
+}
+
+/*
+fromImage converts decoded image into picture,
+grayscale images have one channel, images with alpha have four channels and others have three
+*/
+func fromImage(img image.Image) picture {
+	b := img.Bounds()
+	h, w := b.Dy(), b.Dx()
+	channels := 3
+	switch img.ColorModel() {
+	case color.GrayModel, color.Gray16Model:
+		channels = 1
+	case color.RGBAModel, color.RGBA64Model, color.NRGBAModel, color.NRGBA64Model, color.AlphaModel, color.Alpha16Model:
+		channels = 4
+	default:
+		if _, ok := img.(*image.Paletted); ok {
+			channels = 4
+		}
+	}
+	p := picture{channels, h, w, make([]float32, channels*h*w), true}
+	plane := h * w
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			j := y*w + x
+			c := img.At(b.Min.X+x, b.Min.Y+y)
+			if channels == 1 {
+				p.pix[j] = float32(color.GrayModel.Convert(c).(color.Gray).Y)
+				continue
+			}
+			n := color.NRGBAModel.Convert(c).(color.NRGBA)
+			p.pix[j] = float32(n.R)
+			p.pix[plane+j] = float32(n.G)
+			p.pix[2*plane+j] = float32(n.B)
+			if channels == 4 {
+				p.pix[3*plane+j] = float32(n.A)
+			}
+		}
+	}
+	return p.opaque()
+}
+
+/*
+opaque drops alpha channel if all pixels are opaque
+*/
+func (p picture) opaque() picture {
+	if p.channels != 4 {
+		return p
+	}
+	plane := p.height * p.width
+	for _, a := range p.pix[3*plane:] {
+		if a != 255 {
+			return p
+		}
+	}
+	p.channels = 3
+	p.pix = p.pix[:3*plane]
+	return p
+}
+
+/*
+convert converts picture to the specified count of channels
+*/
+func (p picture) convert(channels int) picture {
+	if channels == 0 || channels == p.channels {
+		return p
+	}
+	plane := p.height * p.width
+	r := picture{channels, p.height, p.width, make([]float32, channels*plane), p.bytes}
+	opaque := float32(1)
+	if p.bytes {
+		opaque = 255
+	}
+	for j := 0; j < plane; j++ {
+		var rgba [4]float32
+		if p.channels < 3 {
+			rgba = [4]float32{p.pix[j], p.pix[j], p.pix[j], opaque}
+		} else {
+			rgba = [4]float32{p.pix[j], p.pix[plane+j], p.pix[2*plane+j], opaque}
+			if p.channels == 4 {
+				rgba[3] = p.pix[3*plane+j]
+			}
+		}
+		if channels == 1 {
+			r.pix[j] = 0.299*rgba[0] + 0.587*rgba[1] + 0.114*rgba[2]
+		} else {
+			for c := 0; c < channels; c++ {
+				r.pix[c*plane+j] = rgba[c]
+			}
+		}
+	}
+	return r
+}
+
+/*
+resize scales picture with bilinear interpolation
+*/
+func (p picture) resize(width, height int) picture {
+	if (width == 0 && height == 0) || (width == p.width && height == p.height) {
+		return p
+	}
+	if width == 0 {
+		width = p.width * height / p.height
+	} else if height == 0 {
+		height = p.height * width / p.width
+	}
+	r := picture{p.channels, height, width, make([]float32, p.channels*height*width), p.bytes}
+	sy := float64(p.height) / float64(height)
+	sx := float64(p.width) / float64(width)
+	for c := 0; c < p.channels; c++ {
+		src := p.pix[c*p.height*p.width : (c+1)*p.height*p.width]
+		dst := r.pix[c*height*width : (c+1)*height*width]
+		for y := 0; y < height; y++ {
+			fy := math.Max(0, (float64(y)+0.5)*sy-0.5)
+			y0 := fu.Mini(int(fy), p.height-1)
+			y1 := fu.Mini(y0+1, p.height-1)
+			dy := float32(fy - float64(y0))
+			for x := 0; x < width; x++ {
+				fx := math.Max(0, (float64(x)+0.5)*sx-0.5)
+				x0 := fu.Mini(int(fx), p.width-1)
+				x1 := fu.Mini(x0+1, p.width-1)
+				dx := float32(fx - float64(x0))
+				top := src[y0*p.width+x0]*(1-dx) + src[y0*p.width+x1]*dx
+				bottom := src[y1*p.width+x0]*(1-dx) + src[y1*p.width+x1]*dx
+				dst[y*width+x] = top*(1-dy) + bottom*dy
+			}
+		}
+	}
+	return r
+}
+
+/*
+tensor converts picture into byte tensor or into float32 tensor if picture is not decoded from bytes
+or asFloat is true, in the last case bytes are scaled into [0,1]
+*/
+func (p picture) tensor(layout Layout, asFloat bool) fu.Tensor {
+	plane := p.height * p.width
+	index := func(j int) int { return j }
+	if layout == HWC {
+		index = func(j int) int { return j%plane*p.channels + j/plane }
+	}
+	if p.bytes && !asFloat {
+		v := make([]byte, len(p.pix))
+		for j, x := range p.pix {
+			v[index(j)] = byte(fu.Maxi(0, fu.Mini(255, int(x+0.5))))
+		}
+		return fu.MakeByteTensor(p.channels, p.height, p.width, v)
+	}
+	scale := float32(1)
+	if p.bytes {
+		scale = 255
+	}
+	v := make([]float32, len(p.pix))
+	for j, x := range p.pix {
+		v[index(j)] = x / scale
+	}
+	return fu.MakeFloat32Tensor(p.channels, p.height, p.width, v)
+}
+
+/*
+transform applies channels, resize and layout options to the picture
+*/
+func transform(p picture, opts []interface{}) fu.Tensor {
+	sz := fu.Option(Resize{}, opts).Interface().(Resize)
+	p = p.convert(fu.IntOption(Channels(0), opts)).resize(sz.Width, sz.Height)
+	return p.tensor(Layout(fu.IntOption(CHW, opts)), fu.BoolOption(Float32(false), opts))
+}
+
+func row(names []string, tensor fu.Tensor, label reflect.Value) fu.Struct {
+	lr := fu.Struct{Names: names, Columns: []reflect.Value{reflect.ValueOf(tensor)}}
+	if len(names) > 1 {
+		lr.Columns = append(lr.Columns, label)
+	}
+	return lr
+}
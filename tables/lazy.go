@@ -1,6 +1,7 @@
 package tables
 
 import (
+	"context"
 	"fmt"
 	"go4ml.xyz/base/fu"
 	"go4ml.xyz/base/fu/lazy"
@@ -112,6 +113,25 @@ func (zf Lazy) Parallel(concurrency ...int) Lazy {
 	return Lazy(lazy.Source(zf).Parallel(concurrency...))
 }
 
+/*
+WithContext binds stream to the context, so Drain and Collect return ctx.Err()
+when context is cancelled or its deadline expires, all upstream producers are stopped in this case
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	t, err := csv.Source(iokit.File("dataset.csv")).WithContext(ctx).Parallel().Collect()
+*/
+func (zf Lazy) WithContext(ctx context.Context) Lazy {
+	return Lazy(lazy.Source(zf).WithContext(ctx))
+}
+
+/*
+DrainContext drains stream into sink and stops it when context is done
+*/
+func (zf Lazy) DrainContext(ctx context.Context, sink Sink) error {
+	return zf.WithContext(ctx).Drain(sink)
+}
+
 const iniCollectLength = 13
 const maxChankLength = 10000
 
@@ -398,6 +418,18 @@ func (zf Lazy) Kfold(seed int, kfold int, k int, name string) Lazy {
 	}
 }
 
+/*
+Transform maps rows by function returning new row, false to skip row or error to fail pipeline.
+Function can return RowError to mark bad row which can be skipped by OnError policy,
+the row is set to the input row if RowError does not have it
+
+	validate := func(lr fu.Struct) (fu.Struct, bool, error) {
+		if lr.Int("Age") < 0 {
+			return lr, false, &tables.RowError{Err: zorros.Errorf("negative age")}
+		}
+		return lr, true, nil
+	}
+*/
 func (zf Lazy) Transform(f func(fu.Struct) (fu.Struct, bool, error)) Lazy {
 	return func() lazy.Stream {
 		z := zf()
@@ -407,10 +439,14 @@ func (zf Lazy) Transform(f func(fu.Struct) (fu.Struct, bool, error)) Lazy {
 				return
 			}
 			lr := v.Interface().(fu.Struct)
-			lr, ok, err := f(lr)
+			r, ok, err := f(lr)
 			if err != nil {
+				if re, ok := err.(*RowError); ok && len(re.Row.Names) == 0 {
+					err = &RowError{Row: lr, Err: re.Err}
+				}
 				return fu.False, err
 			}
+			lr = r
 			if !ok {
 				return fu.True, nil
 			}
@@ -0,0 +1,321 @@
+package libsvm
+
+import (
+	"bufio"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Label is the name of label column, Label by default
+type Label string
+
+// Features is the name of features column, Features by default
+type Features string
+
+// Qid is the name of query id column, Qid by default, the column always exists if the option is specified
+type Qid string
+
+// Dense reads features as dense float32 tensor 1x1xWidth
+type Dense int
+
+// Sparse reads features as fu.SparseTensor of width, it's the default with unknown width
+type Sparse int
+
+// ZeroBased means features indices start from 0 instead of 1
+type ZeroBased bool
+
+/*
+Read reads file in LibSVM/SVMlight format into table with label, features and optional query id
+
+	// features as fu.SparseTensor
+	libsvm.Read(iokit.Compressed(iokit.File("a9a.xz")))
+
+	// features as dense fu.Tensor
+	libsvm.Read(iokit.File("mnist.scale"), libsvm.Dense(780), libsvm.Label("Digit"))
+
+Label is float32 value, query id column is int and exists if Qid option is specified
+or the first successfully parsed line has qid, lines without qid have NA query id.
+Comments started by # are ignored.
+*/
+func Read(source interface{}, opts ...interface{}) (*tables.Table, error) {
+	return Source(source, opts...).Collect()
+}
+
+func Source(source interface{}, opts ...interface{}) tables.Lazy {
+	if e, ok := source.(iokit.Input); ok {
+		return lazyread(e, opts...)
+	} else if e, ok := source.(string); ok {
+		return lazyread(iokit.File(e), opts...)
+	} else if rd, ok := source.(io.Reader); ok {
+		return lazyread(iokit.Reader(rd, nil), opts...)
+	}
+	return tables.SourceError(zorros.Errorf("libsvm reader does not know source type %v", reflect.TypeOf(source).String()))
+}
+
+type line struct {
+	label   float32
+	qid     int
+	hasQid  bool
+	indices []int
+	values  []float32
+	lineno  int
+}
+
+func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
+	return func() lazy.Stream {
+		rd, err := source.Open()
+		if err != nil {
+			return lazy.Error(err)
+		}
+		br := bufio.NewReader(rd)
+		base := 1
+		if fu.BoolOption(ZeroBased(false), opts) {
+			base = 0
+		}
+		dense := fu.IntOption(Dense(0), opts)
+		width := fu.IntOption(Sparse(0), opts)
+		if dense > 0 {
+			width = dense
+		}
+		names := []string{fu.StrOption(Label("Label"), opts), fu.StrOption(Features("Features"), opts)}
+		lineno := 0
+		withQid, decided := false, false
+		for _, o := range opts {
+			if _, ok := o.(Qid); ok {
+				withQid, decided = true, true
+				names = append(names, fu.StrOption(Qid("Qid"), opts))
+			}
+		}
+
+		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
+		return func(index uint64) (reflect.Value, error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				if f.Set() {
+					rd.Close()
+				}
+				return reflect.ValueOf(false), nil
+			}
+			if !wc.Wait(index) {
+				return reflect.ValueOf(false), nil
+			}
+			l, err := readLine(br, &lineno, base, width)
+			if err == nil {
+				if !decided {
+					// bad lines are skipped, so query id column is decided by the first parsed line
+					decided = true
+					if l.hasQid {
+						withQid = true
+						names = append(names, fu.StrOption(Qid("Qid"), opts))
+					}
+				}
+				lr := fu.Struct{Names: names, Columns: make([]reflect.Value, len(names))}
+				lr.Columns[0] = reflect.ValueOf(l.label)
+				if dense > 0 {
+					x := make([]float32, dense)
+					for i, j := range l.indices {
+						x[j] = l.values[i]
+					}
+					lr.Columns[1] = reflect.ValueOf(fu.MakeFloat32Tensor(1, 1, dense, x))
+				} else {
+					lr.Columns[1] = reflect.ValueOf(fu.MakeSparseTensor(width, l.indices, l.values))
+				}
+				if withQid {
+					lr.Columns[2] = reflect.ValueOf(l.qid)
+					lr.Na.Set(2, !l.hasQid)
+				}
+				wc.Inc()
+				return reflect.ValueOf(lr), nil
+			}
+			if _, ok := err.(*tables.RowError); ok {
+				wc.Inc()
+				return reflect.ValueOf(false), err
+			}
+			wc.Stop()
+			if f.Set() {
+				rd.Close()
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			return reflect.ValueOf(false), err
+		}
+	}
+}
+
+/*
+readLine reads and parses the next not empty line, indices are converted to zero-based
+*/
+func readLine(br *bufio.Reader, lineno *int, base, width int) (l line, err error) {
+	for {
+		var s string
+		s, err = br.ReadString('\n')
+		if err != nil && (err != io.EOF || s == "") {
+			return
+		}
+		err = nil
+		*lineno++
+		if j := strings.IndexByte(s, '#'); j >= 0 {
+			s = s[:j]
+		}
+		fs := strings.Fields(s)
+		if len(fs) == 0 {
+			continue
+		}
+		l.lineno = *lineno
+		if err = l.parse(fs, base, width); err != nil {
+			// the line is consumed, so reader can continue with the next one
+			err = &tables.RowError{Err: err}
+		}
+		return
+	}
+}
+
+func (l *line) parse(fs []string, base, width int) error {
+	label, err := strconv.ParseFloat(fs[0], 32)
+	if err != nil {
+		return zorros.Errorf("invalid label %v at line %d", fs[0], l.lineno)
+	}
+	l.label = float32(label)
+	l.indices = make([]int, 0, len(fs)-1)
+	l.values = make([]float32, 0, len(fs)-1)
+	for _, x := range fs[1:] {
+		j := strings.IndexByte(x, ':')
+		if j < 0 {
+			return zorros.Errorf("invalid feature %v at line %d", x, l.lineno)
+		}
+		if x[:j] == "qid" {
+			if l.qid, err = strconv.Atoi(x[j+1:]); err != nil {
+				return zorros.Errorf("invalid qid %v at line %d", x, l.lineno)
+			}
+			l.hasQid = true
+			continue
+		}
+		i, err := strconv.Atoi(x[:j])
+		if err != nil || i < base {
+			return zorros.Errorf("invalid feature index %v at line %d", x, l.lineno)
+		}
+		i -= base
+		if len(l.indices) > 0 && i <= l.indices[len(l.indices)-1] {
+			return zorros.Errorf("feature indices are not in ascending order at line %d", l.lineno)
+		}
+		if width > 0 && i >= width {
+			return zorros.Errorf("feature index %v is out of width %d at line %d", x[:j], width, l.lineno)
+		}
+		v, err := strconv.ParseFloat(x[j+1:], 32)
+		if err != nil {
+			return zorros.Errorf("invalid feature value %v at line %d", x, l.lineno)
+		}
+		l.indices = append(l.indices, i)
+		l.values = append(l.values, float32(v))
+	}
+	return nil
+}
+
+/*
+Write writes table in LibSVM/SVMlight format, zero features are not written
+
+	libsvm.Write(t, iokit.File("train.svm"), libsvm.Label("Target"))
+
+Features column can be fu.SparseTensor or any fu.Tensor, label column has to be numeric,
+query id is written if Qid column exists
+*/
+func Write(t *tables.Table, dest iokit.Output, opts ...interface{}) error {
+	return t.Lazy().Drain(Sink(dest, opts...))
+}
+
+func Sink(dest iokit.Output, opts ...interface{}) tables.Sink {
+	var err error
+	f := iokit.Whole(nil)
+	if f, err = dest.Create(); err != nil {
+		return tables.SinkError(err)
+	}
+	wr := bufio.NewWriter(f)
+	base := 1
+	if fu.BoolOption(ZeroBased(false), opts) {
+		base = 0
+	}
+	label, features, qid := -1, -1, -1
+	return func(v reflect.Value) (err error) {
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				if err = wr.Flush(); err == nil {
+					err = f.Commit()
+				}
+			}
+			f.End()
+			return
+		}
+		lr := v.Interface().(fu.Struct)
+		if label < 0 {
+			if label = lr.Pos(fu.StrOption(Label("Label"), opts)); label < 0 {
+				return zorros.Errorf("label column does not exist")
+			}
+			if features = lr.Pos(fu.StrOption(Features("Features"), opts)); features < 0 {
+				return zorros.Errorf("features column does not exist")
+			}
+			qid = lr.Pos(fu.StrOption(Qid("Qid"), opts))
+		}
+		b := make([]byte, 0, 128)
+		if b, err = appendNumber(b, lr.Columns[label]); err != nil {
+			return
+		}
+		if qid >= 0 && !lr.Na.Bit(qid) {
+			b = append(b, " qid:"...)
+			if b, err = appendNumber(b, lr.Columns[qid]); err != nil {
+				return
+			}
+		}
+		switch x := lr.Columns[features].Interface().(type) {
+		case fu.SparseTensor:
+			for i, j := range x.Indices {
+				b = appendFeature(b, j+base, x.Values[i])
+			}
+		case fu.Tensor:
+			for j, y := range x.Floats32() {
+				if y != 0 {
+					b = appendFeature(b, j+base, y)
+				}
+			}
+		default:
+			return zorros.Errorf("features column has unsupported type %v", lr.Columns[features].Type())
+		}
+		b = append(b, '\n')
+		_, err = wr.Write(b)
+		return
+	}
+}
+
+func appendFeature(b []byte, j int, v float32) []byte {
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(j), 10)
+	b = append(b, ':')
+	return strconv.AppendFloat(b, float64(v), 'g', -1, 32)
+}
+
+func appendNumber(b []byte, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Float32:
+		return strconv.AppendFloat(b, v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.AppendFloat(b, v.Float(), 'g', -1, 64), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(b, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(b, v.Uint(), 10), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, '1'), nil
+		}
+		return append(b, '0'), nil
+	}
+	return b, zorros.Errorf("unsupported label or qid type %v", v.Type())
+}
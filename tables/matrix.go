@@ -88,6 +88,8 @@ func (t *Table) MatrixWithLabelIf(features []string, label string, ifName string
 		c := t.Col(n)
 		if c.Type() == fu.TensorType {
 			width += c.Inspect().([]fu.Tensor)[0].Volume()
+		} else if c.Type() == fu.SparseTensorType {
+			width += sparseWidth(c.Inspect().([]fu.SparseTensor))
 		} else {
 			width++
 		}
@@ -223,6 +225,18 @@ func (t *Table) addToMatrix(f func(int) int, matrix []Matrix, c *Column, label b
 			z[jf]++
 		}
 		wc += vol
+	case fu.SparseTensorType:
+		x := c.Inspect().([]fu.SparseTensor)
+		vol := sparseWidth(x)
+		for j := 0; j < length; j++ {
+			jf := f(j)
+			m := z[jf]
+			for i, k := range x[j].Indices {
+				where[jf][m*width+wc+k] = x[j].Values[i]
+			}
+			z[jf]++
+		}
+		wc += vol
 	default:
 		x := c.ExtractAs(fu.Float32, true).([]float32)
 		for j := 0; j < length; j++ {
@@ -0,0 +1,393 @@
+package tables
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/iokit"
+	"golang.org/x/xerrors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var npyMagic = []byte("\x93NUMPY")
+
+/*
+npyArray is a numpy array decoded into go slice of the corresponding type
+*/
+type npyArray struct {
+	shape  []int
+	values reflect.Value
+}
+
+func (a npyArray) length() int {
+	if len(a.shape) == 0 {
+		return 1
+	}
+	return a.shape[0]
+}
+
+// volume returns count of values in one row
+func (a npyArray) volume() int {
+	v := 1
+	for _, x := range a.shape[fu.Mini(1, len(a.shape)):] {
+		v *= x
+	}
+	return v
+}
+
+func (a npyArray) floats32() ([]float32, error) {
+	if a.values.Type().Elem() == fu.Float32 {
+		return a.values.Interface().([]float32), nil
+	}
+	switch a.values.Type().Elem().Kind() {
+	case reflect.Bool, reflect.String:
+		return nil, xerrors.Errorf("numpy array of %v can't be converted to float32", a.values.Type().Elem())
+	}
+	return fu.ConvertSlice(a.values, fu.Bits{}, fu.Float32).Interface().([]float32), nil
+}
+
+func writeNpy(wr io.Writer, shape []int, values interface{}) (err error) {
+	var descr string
+	switch values.(type) {
+	case []float32:
+		descr = "<f4"
+	case []float64:
+		descr = "<f8"
+	case []byte:
+		descr = "|u1"
+	default:
+		return xerrors.Errorf("unsupported numpy array type %v", reflect.TypeOf(values))
+	}
+	s := make([]string, len(shape))
+	for i, x := range shape {
+		s[i] = strconv.Itoa(x)
+	}
+	dims := strings.Join(s, ", ")
+	if len(shape) == 1 {
+		dims += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, dims)
+	// header is padded by spaces and ended by \n so data is aligned to 64 bytes
+	pad := 64 - (len(npyMagic)+4+len(header)+1)%64
+	header += strings.Repeat(" ", pad%64) + "\n"
+	b := append(append([]byte{}, npyMagic...), 1, 0, 0, 0)
+	binary.LittleEndian.PutUint16(b[len(npyMagic)+2:], uint16(len(header)))
+	if _, err = wr.Write(append(b, header...)); err != nil {
+		return
+	}
+	return binary.Write(wr, binary.LittleEndian, values)
+}
+
+func readNpy(rd io.Reader) (a npyArray, err error) {
+	b := make([]byte, len(npyMagic)+2)
+	if _, err = io.ReadFull(rd, b); err != nil {
+		return
+	}
+	if !bytes.Equal(b[:len(npyMagic)], npyMagic) {
+		return a, xerrors.Errorf("it's not numpy array")
+	}
+	var hlen int
+	if b[len(npyMagic)] == 1 {
+		var l uint16
+		err = binary.Read(rd, binary.LittleEndian, &l)
+		hlen = int(l)
+	} else {
+		var l uint32
+		err = binary.Read(rd, binary.LittleEndian, &l)
+		hlen = int(l)
+	}
+	if err != nil {
+		return
+	}
+	h := make([]byte, hlen)
+	if _, err = io.ReadFull(rd, h); err != nil {
+		return
+	}
+	header := string(h)
+	descr := npyHeaderValue(header, "descr")
+	if strings.HasPrefix(npyHeaderValue(header, "fortran_order"), "True") {
+		return a, xerrors.Errorf("numpy arrays in fortran order are not supported")
+	}
+	shape := strings.Trim(npyHeaderValue(header, "shape"), "()")
+	volume := 1
+	for _, x := range strings.Split(shape, ",") {
+		if x = strings.TrimSpace(x); x != "" {
+			n, e := strconv.Atoi(x)
+			if e != nil {
+				return a, xerrors.Errorf("invalid numpy array shape (%v)", shape)
+			}
+			a.shape = append(a.shape, n)
+			volume *= n
+		}
+	}
+	descr = strings.Trim(descr, "'\"")
+	if len(descr) < 3 {
+		return a, xerrors.Errorf("invalid numpy array dtype %v", descr)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if descr[0] == '>' {
+		order = binary.BigEndian
+	}
+	var x interface{}
+	switch descr[1:] {
+	case "f4":
+		x = make([]float32, volume)
+	case "f8":
+		x = make([]float64, volume)
+	case "u1":
+		x = make([]byte, volume)
+	case "i1":
+		x = make([]int8, volume)
+	case "b1":
+		x = make([]bool, volume)
+	case "i2":
+		x = make([]int16, volume)
+	case "u2":
+		x = make([]uint16, volume)
+	case "i4":
+		x = make([]int32, volume)
+	case "u4":
+		x = make([]uint32, volume)
+	case "i8":
+		x = make([]int64, volume)
+	case "u8":
+		x = make([]uint64, volume)
+	default:
+		return a, xerrors.Errorf("unsupported numpy array dtype %v", descr)
+	}
+	if err = binary.Read(rd, order, x); err != nil {
+		return
+	}
+	a.values = reflect.ValueOf(x)
+	return
+}
+
+func npyHeaderValue(header, key string) string {
+	k := strings.Index(header, "'"+key+"'")
+	if k < 0 {
+		return ""
+	}
+	s := strings.TrimSpace(header[k+len(key)+2:])
+	s = strings.TrimSpace(strings.TrimPrefix(s, ":"))
+	if strings.HasPrefix(s, "(") {
+		if j := strings.Index(s, ")"); j >= 0 {
+			return s[:j+1]
+		}
+	}
+	if j := strings.IndexAny(s, ",}"); j >= 0 {
+		return strings.TrimSpace(s[:j])
+	}
+	return s
+}
+
+func withOutput(dest iokit.Output, f func(io.Writer) error) (err error) {
+	w, err := dest.Create()
+	if err != nil {
+		return
+	}
+	defer w.End()
+	if err = f(w); err != nil {
+		return
+	}
+	return w.Commit()
+}
+
+func (m Matrix) features() ([]int, []float32) {
+	return []int{m.Length, m.Width}, m.Features[:m.Length*m.Width]
+}
+
+func (m Matrix) labels() ([]int, []float32) {
+	if m.LabelsWidth == 1 {
+		return []int{m.Length}, m.Labels[:m.Length]
+	}
+	return []int{m.Length, m.LabelsWidth}, m.Labels[:m.Length*m.LabelsWidth]
+}
+
+/*
+WriteNpy writes features as float32 numpy array of shape (Length, Width)
+and labels if they are defined and labels output is specified
+
+	m.WriteNpy(iokit.File("features.npy"), iokit.File("labels.npy"))
+*/
+func (m Matrix) WriteNpy(features iokit.Output, labels ...iokit.Output) (err error) {
+	err = withOutput(features, func(w io.Writer) error {
+		shape, values := m.features()
+		return writeNpy(w, shape, values)
+	})
+	if err == nil && m.LabelsWidth > 0 && len(labels) > 0 {
+		err = withOutput(labels[0], func(w io.Writer) error {
+			shape, values := m.labels()
+			return writeNpy(w, shape, values)
+		})
+	}
+	return
+}
+
+/*
+WriteNpz writes features and labels as numpy archive with arrays features and labels,
+labels array has shape (Length,) if labels width is 1
+
+	m.WriteNpz(iokit.File("dataset.npz"))
+
+	// python
+	with np.load("dataset.npz") as ds:
+		x, y = ds["features"], ds["labels"]
+*/
+func (m Matrix) WriteNpz(dest iokit.Output) error {
+	return withOutput(dest, func(w io.Writer) (err error) {
+		zw := zip.NewWriter(w)
+		f, err := zw.Create("features.npy")
+		if err != nil {
+			return
+		}
+		shape, values := m.features()
+		if err = writeNpy(f, shape, values); err != nil {
+			return
+		}
+		if m.LabelsWidth > 0 {
+			if f, err = zw.Create("labels.npy"); err != nil {
+				return
+			}
+			shape, values := m.labels()
+			if err = writeNpy(f, shape, values); err != nil {
+				return
+			}
+		}
+		return zw.Close()
+	})
+}
+
+func readNpyInput(source iokit.Input) (a npyArray, err error) {
+	rd, err := source.Open()
+	if err != nil {
+		return
+	}
+	defer rd.Close()
+	return readNpy(rd)
+}
+
+/*
+ReadNpy reads matrix features and optionally labels from numpy arrays,
+arrays are converted to float32, the first dimension is the matrix length
+
+	m, err := tables.ReadNpy(iokit.File("features.npy"), iokit.File("labels.npy"))
+*/
+func ReadNpy(features iokit.Input, labels ...iokit.Input) (m Matrix, err error) {
+	a, err := readNpyInput(features)
+	if err != nil {
+		return
+	}
+	m.Length, m.Width = a.length(), a.volume()
+	if m.Features, err = a.floats32(); err != nil {
+		return
+	}
+	if len(labels) > 0 {
+		if a, err = readNpyInput(labels[0]); err != nil {
+			return
+		}
+		if a.length() != m.Length {
+			return m, xerrors.Errorf("labels length %d does not match features length %d", a.length(), m.Length)
+		}
+		m.LabelsWidth = a.volume()
+		m.Labels, err = a.floats32()
+	}
+	return
+}
+
+/*
+ReadNpz reads numpy archive of equally long arrays into table,
+1-D arrays are scalar columns and N-D arrays are tensor columns
+where the first dimension is the table length and others are tensor dimensions
+
+	// python
+	np.savez("dataset.npz", images=images, label=labels)
+
+	t, err := tables.ReadNpz(iokit.File("dataset.npz"))
+	t.Col("images").Type() -> fu.TensorType
+*/
+func ReadNpz(source iokit.Input) (t *Table, err error) {
+	rd, err := source.Open()
+	if err != nil {
+		return
+	}
+	bs, err := ioutil.ReadAll(rd)
+	rd.Close()
+	if err != nil {
+		return
+	}
+	zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+	if err != nil {
+		return nil, xerrors.Errorf("it's not numpy archive: %w", err)
+	}
+	names := []string{}
+	columns := []reflect.Value{}
+	length := -1
+	for _, f := range zr.File {
+		var r io.ReadCloser
+		if r, err = f.Open(); err != nil {
+			return
+		}
+		a, err := readNpy(r)
+		r.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read %v: %w", f.Name, err)
+		}
+		if length >= 0 && a.length() != length {
+			return nil, xerrors.Errorf("array %v has length %d but previous arrays have %d", f.Name, a.length(), length)
+		}
+		length = a.length()
+		var c reflect.Value
+		if c, err = npyColumn(a); err != nil {
+			return nil, xerrors.Errorf("failed to read %v: %w", f.Name, err)
+		}
+		names = append(names, strings.TrimSuffix(f.Name, ".npy"))
+		columns = append(columns, c)
+	}
+	return MakeTable(names, columns, make([]fu.Bits, len(columns)), fu.Maxi(length, 0)), nil
+}
+
+func npyColumn(a npyArray) (reflect.Value, error) {
+	if len(a.shape) <= 1 {
+		switch a.values.Type().Elem().Kind() {
+		case reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint16, reflect.Uint32:
+			return fu.ConvertSlice(a.values, fu.Bits{}, fu.Int), nil
+		}
+		return a.values, nil
+	}
+	dims := []int{1, 1, 1}
+	if len(a.shape) > 4 {
+		return reflect.Value{}, xerrors.Errorf("arrays with more than 4 dimensions are not supported")
+	}
+	copy(dims[4-len(a.shape):], a.shape[1:])
+	n, vol := a.length(), a.volume()
+	column := make([]fu.Tensor, n)
+	switch x := a.values.Interface().(type) {
+	case []float32:
+		for i := range column {
+			column[i] = fu.MakeFloat32Tensor(dims[0], dims[1], dims[2], x[i*vol:(i+1)*vol])
+		}
+	case []float64:
+		for i := range column {
+			column[i] = fu.MakeFloat64Tensor(dims[0], dims[1], dims[2], x[i*vol:(i+1)*vol])
+		}
+	case []byte:
+		for i := range column {
+			column[i] = fu.MakeByteTensor(dims[0], dims[1], dims[2], x[i*vol:(i+1)*vol])
+		}
+	default:
+		if a.values.Type().Elem().Kind() == reflect.Bool {
+			return reflect.Value{}, xerrors.Errorf("tensors of bool are not supported")
+		}
+		y := fu.ConvertSlice(a.values, fu.Bits{}, fu.Int).Interface().([]int)
+		for i := range column {
+			column[i] = fu.MakeIntTensor(dims[0], dims[1], dims[2], y[i*vol:(i+1)*vol])
+		}
+	}
+	return reflect.ValueOf(column), nil
+}
@@ -0,0 +1,179 @@
+package tables
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/fu/verbose"
+	"go4ml.xyz/iokit"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ProgressInfo is a state of pipeline stage passed to progress reporter
+*/
+type ProgressInfo struct {
+	Stage      string
+	Rows       int           // count of rows passed the stage
+	Filtered   int           // count of rows filtered out before the stage
+	Elapsed    time.Duration // time since the stage started
+	RowsPerSec float64
+	Bytes      int64         // bytes read from metered input, 0 if there is no meter
+	Size       int64         // size of metered input, 0 if it's unknown
+	ETA        time.Duration // estimated time to the end, 0 if it's unknown
+	Done       bool          // the stage reached the end of stream
+}
+
+func (p ProgressInfo) String() string {
+	s := fmt.Sprintf("%v: %d rows", p.Stage, p.Rows)
+	if p.Filtered > 0 {
+		s += fmt.Sprintf(" (%d filtered)", p.Filtered)
+	}
+	s += fmt.Sprintf(", %.0f rows/s, %v", p.RowsPerSec, p.Elapsed.Round(time.Millisecond))
+	if p.Size > 0 {
+		s += fmt.Sprintf(", %d%% of %d bytes", p.Bytes*100/p.Size, p.Size)
+		if !p.Done {
+			s += fmt.Sprintf(", ETA %v", p.ETA.Round(time.Second))
+		}
+	} else if p.Bytes > 0 {
+		s += fmt.Sprintf(", %d bytes", p.Bytes)
+	}
+	if p.Done {
+		s += ", done"
+	}
+	return s
+}
+
+// Stage names pipeline stage in progress reports
+type Stage string
+
+/*
+VerboseProgress is the default progress reporter printing progress with fu/verbose
+*/
+func VerboseProgress(p ProgressInfo) {
+	verbose.Printf("%v", p)
+}
+
+/*
+Meter counts bytes read from input, it can be passed to Progress to report bytes read and ETA
+
+	m := tables.Metered(iokit.File("dataset.csv"))
+	err := csv.Source(m).
+		Progress(100000, tables.VerboseProgress, m, tables.Stage("read")).
+		Drain(sink)
+*/
+type Meter struct {
+	Input iokit.Input
+	bytes int64
+	size  int64
+}
+
+/*
+Metered wraps input into Meter
+*/
+func Metered(input iokit.Input) *Meter {
+	return &Meter{Input: input}
+}
+
+func (m *Meter) Open() (io.ReadCloser, error) {
+	rd, err := m.Input.Open()
+	if err != nil {
+		return nil, err
+	}
+	atomic.StoreInt64(&m.bytes, 0)
+	atomic.StoreInt64(&m.size, iokit.FileSize(rd))
+	return &meteredReader{rd, m}, nil
+}
+
+/*
+Bytes returns count of bytes read and size of input if it's known
+*/
+func (m *Meter) Bytes() (bytes int64, size int64) {
+	return atomic.LoadInt64(&m.bytes), atomic.LoadInt64(&m.size)
+}
+
+type meteredReader struct {
+	io.ReadCloser
+	m *Meter
+}
+
+func (r *meteredReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.m.bytes, int64(n))
+	return
+}
+
+/*
+Progress calls report every `every` rows passed the stage and once at the end of stream
+
+	err := csv.Source(iokit.File("dataset.csv")).
+		Filter(valid).
+		Progress(10000, tables.VerboseProgress, tables.Stage("valid")).
+		Drain(sink)
+
+Rows skipped by upstream filters are counted as filtered and also trigger reporting.
+Report is called from the goroutine reading the stream and can be called concurrently under Parallel,
+calls are serialized.
+*/
+func (zf Lazy) Progress(every int, report func(ProgressInfo), opts ...interface{}) Lazy {
+	stage := fu.StrOption(Stage("stage"), opts)
+	meter := fu.Option((*Meter)(nil), opts).Interface().(*Meter)
+	every = fu.Maxi(1, every)
+	return func() lazy.Stream {
+		z := zf()
+		start := time.Now()
+		rows, filtered, seen := int64(0), int64(0), int64(0)
+		mu := sync.Mutex{}
+		f := fu.AtomicFlag{Value: 0}
+		info := func(done bool) ProgressInfo {
+			p := ProgressInfo{
+				Stage:    stage,
+				Rows:     int(atomic.LoadInt64(&rows)),
+				Filtered: int(atomic.LoadInt64(&filtered)),
+				Elapsed:  time.Since(start),
+				Done:     done,
+			}
+			if s := p.Elapsed.Seconds(); s > 0 {
+				p.RowsPerSec = float64(p.Rows) / s
+			}
+			if meter != nil {
+				p.Bytes, p.Size = meter.Bytes()
+				if p.Size > 0 && p.Bytes > 0 && p.Bytes < p.Size {
+					p.ETA = time.Duration(float64(p.Elapsed) * float64(p.Size-p.Bytes) / float64(p.Bytes))
+				}
+			}
+			return p
+		}
+		return func(index uint64) (v reflect.Value, err error) {
+			if index == lazy.STOP {
+				return z(index)
+			}
+			if v, err = z(index); err != nil {
+				return
+			}
+			if v.Kind() == reflect.Bool {
+				if !v.Bool() {
+					if f.Set() {
+						mu.Lock()
+						report(info(true))
+						mu.Unlock()
+					}
+					return
+				}
+				atomic.AddInt64(&filtered, 1)
+			} else {
+				atomic.AddInt64(&rows, 1)
+			}
+			if atomic.AddInt64(&seen, 1)%int64(every) == 0 {
+				mu.Lock()
+				report(info(false))
+				mu.Unlock()
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package rdb
+
+import (
+	"database/sql"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"reflect"
+	"strings"
+)
+
+/*
+Dialect encapsulates differences between SQL databases
+*/
+type Dialect interface {
+	// Quote quotes identifier
+	Quote(name string) string
+	// Table returns quoted table name qualified by schema if it's not empty
+	Table(schema, table string) string
+	// SchemaStmt returns statement selecting default schema of connection or empty string
+	SchemaStmt(schema string) string
+	// Placeholder returns placeholder of n-th statement argument starting from 1
+	Placeholder(n int) string
+	// TypeOf returns SQL type to store values of go type
+	TypeOf(tp reflect.Type) string
+	// AutoIncrement returns SQL type of autoincrement integer column
+	AutoIncrement() string
+	// Upsert returns conflict handling part of insert statement for quoted column names
+	Upsert(names []string, pk []bool) string
+	// Value converts value to database/sql statement argument
+	Value(v reflect.Value) (interface{}, error)
+	// Tables lists tables of schema or of default schema if it's empty
+	Tables(db *sql.DB, schema string) ([]string, error)
+	// Columns describes columns of table
+	Columns(db *sql.DB, schema, table string) ([]ColumnInfo, error)
+}
+
+var (
+	Generic  Dialect = generic{}
+	Sqlite   Dialect = sqlite{}
+	Postgres Dialect = postgres{}
+	Mysql    Dialect = mysql{}
+)
+
+var dialects = map[string]Dialect{
+	"sqlite3":  Sqlite,
+	"postgres": Postgres,
+	"pgx":      Postgres,
+	"mysql":    Mysql,
+}
+
+/*
+RegisterDialect associates dialect with database/sql driver name,
+it's not thread safe and intended to be called from init function
+*/
+func RegisterDialect(driver string, d Dialect) {
+	dialects[driver] = d
+}
+
+/*
+DialectOf returns dialect of database/sql driver or Generic if driver is unknown
+*/
+func DialectOf(driver string) Dialect {
+	if d, ok := dialects[driver]; ok {
+		return d
+	}
+	return Generic
+}
+
+func dialectOf(opts []interface{}) Dialect {
+	for _, o := range opts {
+		if d, ok := o.(Dialect); ok {
+			return d
+		}
+	}
+	return DialectOf(fu.StrOption(Driver(""), opts))
+}
+
+/*
+driverOf returns driver name registered for dialect, so column types are resolved for dialect
+specified by option as well as for dialect of driver
+*/
+func driverOf(d Dialect, driver string) string {
+	if DialectOf(driver) == d {
+		return driver
+	}
+	for k, x := range dialects {
+		if x == d {
+			return k
+		}
+	}
+	return driver
+}
+
+func quoteWith(q string, name string) string {
+	return q + strings.Replace(name, q, q+q, -1) + q
+}
+
+func qualified(d Dialect, schema, table string) string {
+	if schema != "" {
+		return d.Quote(schema) + "." + d.Quote(table)
+	}
+	return d.Quote(table)
+}
+
+func quoteAll(d Dialect, names []string) []string {
+	r := make([]string, len(names))
+	for i, n := range names {
+		r[i] = d.Quote(n)
+	}
+	return r
+}
+
+func commonTypeOf(tp reflect.Type) string {
+	switch tp.Kind() {
+	case reflect.String:
+		return "TEXT"
+	case reflect.Int8, reflect.Uint8, reflect.Int16:
+		return "SMALLINT"
+	case reflect.Uint16, reflect.Int32, reflect.Int:
+		return "INTEGER"
+	case reflect.Uint, reflect.Uint32, reflect.Int64, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "FLOAT"
+	case reflect.Float64:
+		return "DOUBLE"
+	case reflect.Bool:
+		return "BOOLEAN"
+	}
+	switch tp {
+	case fu.Ts:
+		return "TIMESTAMP"
+	case fu.Fixed8Type:
+		return "DECIMAL(3,2)"
+	case fu.TensorType:
+		return "BLOB"
+	}
+	panic("unsupported data type " + fmt.Sprintf("%v %v", tp.String(), tp.Kind()))
+}
+
+func commonValue(v reflect.Value) (interface{}, error) {
+	switch v.Type() {
+	case fu.Fixed8Type:
+		return float64(v.Interface().(fu.Fixed8).Float32()), nil
+	case fu.TensorType:
+		return v.Interface().(fu.Tensor).MarshalBinary()
+	}
+	return v.Interface(), nil
+}
+
+func conflictUpsert(names []string, pk []bool) string {
+	keys := []string{}
+	update := []string{}
+	for i, n := range names {
+		if pk[i] {
+			keys = append(keys, n)
+		} else {
+			update = append(update, n+" = excluded."+n)
+		}
+	}
+	q := " on conflict (" + strings.Join(keys, ",") + ")"
+	if len(update) == 0 {
+		return q + " do nothing"
+	}
+	return q + " do update set " + strings.Join(update, ", ")
+}
+
+type generic struct{}
+
+func (generic) Quote(name string) string                   { return quoteWith(`"`, name) }
+func (d generic) Table(schema, table string) string        { return qualified(d, schema, table) }
+func (generic) SchemaStmt(string) string                   { return "" }
+func (generic) Placeholder(int) string                     { return "?" }
+func (generic) TypeOf(tp reflect.Type) string              { return commonTypeOf(tp) }
+func (generic) AutoIncrement() string                      { return "INTEGER NOT NULL AUTOINCREMENT" }
+func (generic) Upsert(names []string, pk []bool) string    { return conflictUpsert(names, pk) }
+func (generic) Value(v reflect.Value) (interface{}, error) { return commonValue(v) }
+
+type sqlite struct{ generic }
+
+func (d sqlite) Table(schema, table string) string { return qualified(d, schema, table) }
+
+func (sqlite) TypeOf(tp reflect.Type) string {
+	if tp == fu.Ts {
+		return "DATETIME"
+	}
+	return commonTypeOf(tp)
+}
+
+type postgres struct{ generic }
+
+func (d postgres) Table(schema, table string) string { return qualified(d, schema, table) }
+func (d postgres) SchemaStmt(schema string) string   { return "set search_path to " + d.Quote(schema) }
+func (postgres) Placeholder(n int) string            { return fmt.Sprintf("$%d", n) }
+func (postgres) AutoIncrement() string               { return "SERIAL NOT NULL" }
+
+func (postgres) TypeOf(tp reflect.Type) string {
+	switch tp.Kind() {
+	case reflect.String:
+		return "VARCHAR(65535)" /* redshift TEXT == VARCHAR(256) */
+	case reflect.Float32:
+		return "REAL" /* redshift does not FLOAT */
+	case reflect.Float64:
+		return "DOUBLE PRECISION" /* redshift does not have DOUBLE */
+	}
+	if tp == fu.TensorType {
+		return "BYTEA"
+	}
+	return commonTypeOf(tp)
+}
+
+type mysql struct{ generic }
+
+func (mysql) Quote(name string) string            { return quoteWith("`", name) }
+func (d mysql) Table(schema, table string) string { return qualified(d, schema, table) }
+func (d mysql) SchemaStmt(schema string) string   { return "use " + d.Quote(schema) }
+func (mysql) AutoIncrement() string               { return "INTEGER NOT NULL AUTO_INCREMENT" }
+
+func (mysql) TypeOf(tp reflect.Type) string {
+	switch tp {
+	case fu.Ts:
+		return "DATETIME"
+	case fu.TensorType:
+		return "LONGBLOB"
+	}
+	return commonTypeOf(tp)
+}
+
+func (mysql) Upsert(names []string, pk []bool) string {
+	update := []string{}
+	key := ""
+	for i, n := range names {
+		if pk[i] {
+			key = n
+		} else {
+			update = append(update, n+" = values("+n+")")
+		}
+	}
+	if len(update) == 0 {
+		// there is nothing to update, so keep the existing row
+		return " on duplicate key update " + key + " = " + key
+	}
+	return " on duplicate key update " + strings.Join(update, ", ")
+}
@@ -6,14 +6,19 @@ import (
 	"go4ml.xyz/base/fu"
 	"go4ml.xyz/zorros"
 	"reflect"
+	"strconv"
 )
 
 type IfExists_ int
 
 const (
+	// ErrorIfExists fails when the table already exists
 	ErrorIfExists IfExists_ = iota
+	// DropIfExists drops existing table and creates new one
 	DropIfExists
+	// AppendIfExists inserts rows into existing table validating columns compatibility
 	AppendIfExists
+	// InsertUpdateIfExists upserts rows keyed on PrimaryKey() columns
 	InsertUpdateIfExists
 )
 
@@ -23,10 +28,17 @@ type Schema string
 type Driver string
 type Batch int
 
+// CommitEvery commits transaction every N rows, 0 means single transaction
+type CommitEvery int
+
+/*
+SqlTypeOpt describes column type, it gets database/sql driver name and returns
+column name, SQL type, table column name and primary key flag
+*/
 type SqlTypeOpt func(string) (string, string, string, bool)
 
 func Describe(names []string, opts []interface{}) (func(string) (string, string, bool), error) {
-	drv := fu.StrOption(Driver(""), opts)
+	drv := driverOf(dialectOf(opts), fu.StrOption(Driver(""), opts))
 	m := map[string]func() (string, string, bool){}
 	for _, o := range opts {
 		if sto, ok := o.(SqlTypeOpt); ok {
@@ -87,18 +99,12 @@ func BIGINT(v string) SqlTypeOpt {
 }
 func FLOAT(v string) SqlTypeOpt {
 	return func(drv string) (string, string, string, bool) {
-		if drv == "postgres" {
-			return v, "REAL", v, false
-		}
-		return v, "FLOAT", v, false
+		return v, DialectOf(drv).TypeOf(fu.Float32), v, false
 	}
 }
 func DOUBLE(v string) SqlTypeOpt {
 	return func(drv string) (string, string, string, bool) {
-		if drv == "postgres" {
-			return v, "DOUBLE PRECISION", v, false
-		}
-		return v, "DOUBLE", v, false
+		return v, DialectOf(drv).TypeOf(fu.Float64), v, false
 	}
 }
 func DATE(v string) SqlTypeOpt {
@@ -113,7 +119,7 @@ func DATETIME(v string) SqlTypeOpt {
 }
 func TIMESTAMP(v string) SqlTypeOpt {
 	return func(drv string) (string, string, string, bool) {
-		if drv == "sqlite3" {
+		if DialectOf(drv) == Sqlite {
 			return v, "DATETIME", v, false
 		}
 		return v, "TIMESTAMP", v, false
@@ -147,13 +153,13 @@ func VARCHAR(v string, length ...int) SqlTypeOpt {
 
 func AUTOINCREMENT(v string) SqlTypeOpt {
 	return func(drv string) (string, string, string, bool) {
-		switch drv {
-		case "postgres":
-			return v, "SERIAL NOT NULL", v, false
-		case "mysql":
-			return v, "INTEGER NOT NULL AUTO_INCREMENT", v, false
-		}
-		return v, "INTEGER NOT NULL AUTOINCREMENT", v, false
+		return v, DialectOf(drv).AutoIncrement(), v, false
+	}
+}
+
+func BLOB(v string) SqlTypeOpt {
+	return func(drv string) (string, string, string, bool) {
+		return v, DialectOf(drv).TypeOf(fu.TensorType), v, false
 	}
 }
 
@@ -304,3 +310,84 @@ func (s *SqlTimestamp) Reflect() reflect.Type {
 func (s *SqlTimestamp) Scan(value interface{}) error {
 	return s.NullTime.Scan(value)
 }
+
+type SqlTiny struct {
+	sql.NullInt32
+}
+
+func (s *SqlTiny) Scan(value interface{}) error {
+	return s.NullInt32.Scan(value)
+}
+
+func (s *SqlTiny) Value() (reflect.Value, bool) {
+	return reflect.ValueOf(int8(s.Int32)), s.Valid
+}
+
+func (s *SqlTiny) Reflect() reflect.Type {
+	return fu.Int8
+}
+
+/*
+SqlUnsigned scans unsigned integer columns into uint8/16/32/64 values
+*/
+type SqlUnsigned struct {
+	Uint  uint64
+	Valid bool
+	tp    reflect.Type
+}
+
+func (s *SqlUnsigned) Scan(value interface{}) (err error) {
+	s.Uint, s.Valid = 0, value != nil
+	switch v := value.(type) {
+	case nil:
+	case int64:
+		s.Uint = uint64(v)
+	case uint64:
+		s.Uint = v
+	case []byte:
+		s.Uint, err = strconv.ParseUint(string(v), 10, 64)
+	case string:
+		s.Uint, err = strconv.ParseUint(v, 10, 64)
+	default:
+		err = zorros.Errorf("can't scan %v as unsigned integer", reflect.TypeOf(value))
+	}
+	return
+}
+
+func (s *SqlUnsigned) Value() (reflect.Value, bool) {
+	return reflect.ValueOf(s.Uint).Convert(s.tp), s.Valid
+}
+
+func (s *SqlUnsigned) Reflect() reflect.Type {
+	return s.tp
+}
+
+type SqlBytes struct {
+	Bytes []byte
+	Valid bool
+}
+
+func (s *SqlBytes) Scan(value interface{}) error {
+	s.Bytes, s.Valid = nil, value != nil
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		s.Bytes = make([]byte, len(v))
+		copy(s.Bytes, v)
+	case string:
+		s.Bytes = []byte(v)
+	default:
+		return zorros.Errorf("can't scan %v as bytes", reflect.TypeOf(value))
+	}
+	return nil
+}
+
+func (s *SqlBytes) Value() (reflect.Value, bool) {
+	return reflect.ValueOf(s.Bytes), s.Valid
+}
+
+func (s *SqlBytes) Reflect() reflect.Type {
+	return bytesType
+}
+
+var bytesType = reflect.TypeOf([]byte{})
@@ -0,0 +1,198 @@
+package rdb
+
+import (
+	"database/sql"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/zorros"
+	"reflect"
+	"strings"
+)
+
+/*
+ColumnInfo describes table column
+*/
+type ColumnInfo struct {
+	Name       string
+	SqlType    string // SQL type as it's reported by database
+	Nullable   bool
+	PrimaryKey bool
+}
+
+/*
+Type returns go type of column values or nil if SQL type is unknown
+*/
+func (c ColumnInfo) Type() reflect.Type {
+	if s, ok := scannerOf(c.SqlType); ok {
+		return s.Reflect()
+	}
+	return nil
+}
+
+/*
+Tables lists tables of database schema selected by rdb.Schema option or default one
+
+	names, err := rdb.Tables("sqlite3:file:/tmp/test.db")
+*/
+func Tables(source interface{}, opts ...interface{}) (names []string, err error) {
+	db, opts, err := connectDB(source, opts)
+	if err != nil {
+		return nil, zorros.Wrapf(err, "database connection error: %s", err.Error())
+	}
+	if !fu.BoolOption(dontclose(false), opts) {
+		defer db.Close()
+	}
+	return dialectOf(opts).Tables(db, fu.StrOption(Schema(""), opts))
+}
+
+/*
+Columns describes columns of the table
+
+	cols, err := rdb.Columns("sqlite3:file:/tmp/test.db", "maxd")
+	cols[0].Name -> "Id"
+	cols[0].PrimaryKey -> true
+	cols[0].Type() -> reflect.TypeOf("")
+*/
+func Columns(source interface{}, table string, opts ...interface{}) (cols []ColumnInfo, err error) {
+	db, opts, err := connectDB(source, opts)
+	if err != nil {
+		return nil, zorros.Wrapf(err, "database connection error: %s", err.Error())
+	}
+	if !fu.BoolOption(dontclose(false), opts) {
+		defer db.Close()
+	}
+	return dialectOf(opts).Columns(db, fu.StrOption(Schema(""), opts), table)
+}
+
+func queryTables(db *sql.DB, query string, args ...interface{}) (names []string, err error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, zorros.Wrapf(err, "query error: %s", err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n string
+		if err = rows.Scan(&n); err != nil {
+			return nil, zorros.Wrapf(err, "scan error: %s", err.Error())
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+func queryColumns(db *sql.DB, table, query string, args ...interface{}) (cols []ColumnInfo, err error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, zorros.Wrapf(err, "query error: %s", err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := ColumnInfo{}
+		if err = rows.Scan(&c.Name, &c.SqlType, &c.Nullable, &c.PrimaryKey); err != nil {
+			return nil, zorros.Wrapf(err, "scan error: %s", err.Error())
+		}
+		c.SqlType = strings.ToUpper(c.SqlType)
+		cols = append(cols, c)
+	}
+	if err = rows.Err(); err == nil && len(cols) == 0 {
+		err = zorros.Errorf("table %v does not exist", table)
+	}
+	return
+}
+
+func (generic) Tables(db *sql.DB, schema string) ([]string, error) {
+	if schema != "" {
+		return queryTables(db, "select table_name from information_schema.tables where table_schema = ? order by table_name", schema)
+	}
+	return queryTables(db, "select table_name from information_schema.tables order by table_name")
+}
+
+/*
+Columns of generic dialect relies on database/sql column types,
+so it does not know primary key columns
+*/
+func (d generic) Columns(db *sql.DB, schema, table string) (cols []ColumnInfo, err error) {
+	rows, err := db.Query("select * from " + d.Table(schema, table) + " where 1=0")
+	if err != nil {
+		return nil, zorros.Wrapf(err, "query error: %s", err.Error())
+	}
+	defer rows.Close()
+	tps, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, zorros.Wrapf(err, "get types error: %s", err.Error())
+	}
+	for _, t := range tps {
+		nullable, ok := t.Nullable()
+		cols = append(cols, ColumnInfo{
+			Name:     t.Name(),
+			SqlType:  strings.ToUpper(t.DatabaseTypeName()),
+			Nullable: nullable || !ok})
+	}
+	return
+}
+
+func (d sqlite) Tables(db *sql.DB, schema string) ([]string, error) {
+	master := "sqlite_master"
+	if schema != "" {
+		master = d.Quote(schema) + "." + master
+	}
+	return queryTables(db, "select name from "+master+" where type = 'table' and name not like 'sqlite_%' order by name")
+}
+
+func (d sqlite) Columns(db *sql.DB, schema, table string) (cols []ColumnInfo, err error) {
+	pragma := "pragma "
+	if schema != "" {
+		pragma += d.Quote(schema) + "."
+	}
+	rows, err := db.Query(pragma + "table_info(" + d.Quote(table) + ")")
+	if err != nil {
+		return nil, zorros.Wrapf(err, "query error: %s", err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notnull, pk int
+		var dflt interface{}
+		c := ColumnInfo{}
+		if err = rows.Scan(&cid, &c.Name, &c.SqlType, &notnull, &dflt, &pk); err != nil {
+			return nil, zorros.Wrapf(err, "scan error: %s", err.Error())
+		}
+		c.SqlType = strings.ToUpper(c.SqlType)
+		c.Nullable = notnull == 0 && pk == 0
+		c.PrimaryKey = pk > 0
+		cols = append(cols, c)
+	}
+	if err = rows.Err(); err == nil && len(cols) == 0 {
+		err = zorros.Errorf("table %v does not exist", table)
+	}
+	return
+}
+
+func (postgres) Tables(db *sql.DB, schema string) ([]string, error) {
+	return queryTables(db, `select table_name from information_schema.tables
+		where table_schema = coalesce(nullif($1, ''), current_schema()) and table_type = 'BASE TABLE'
+		order by table_name`, schema)
+}
+
+func (postgres) Columns(db *sql.DB, schema, table string) ([]ColumnInfo, error) {
+	return queryColumns(db, table, `select c.column_name, c.data_type, c.is_nullable = 'YES',
+			exists (select 1 from information_schema.table_constraints t
+				join information_schema.key_column_usage k
+				on k.constraint_name = t.constraint_name and k.table_schema = t.table_schema and k.table_name = t.table_name
+				where t.constraint_type = 'PRIMARY KEY' and t.table_schema = c.table_schema
+				and t.table_name = c.table_name and k.column_name = c.column_name)
+		from information_schema.columns c
+		where c.table_schema = coalesce(nullif($1, ''), current_schema()) and c.table_name = $2
+		order by c.ordinal_position`, schema, table)
+}
+
+func (mysql) Tables(db *sql.DB, schema string) ([]string, error) {
+	return queryTables(db, `select table_name from information_schema.tables
+		where table_schema = coalesce(nullif(?, ''), database()) and table_type = 'BASE TABLE'
+		order by table_name`, schema)
+}
+
+func (mysql) Columns(db *sql.DB, schema, table string) ([]ColumnInfo, error) {
+	return queryColumns(db, table, `select column_name, column_type, is_nullable = 'YES', column_key = 'PRI'
+		from information_schema.columns
+		where table_schema = coalesce(nullif(?, ''), database()) and table_name = ?
+		order by ordinal_position`, schema, table)
+}
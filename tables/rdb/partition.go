@@ -0,0 +1,440 @@
+package rdb
+
+import (
+	"database/sql"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Partition_ struct {
+	column string
+	parts  int
+}
+
+/*
+Partition splits reading of the table by ranges of integer or timestamp column
+into N concurrent queries merged into one stream, so rows order is not preserved.
+Rows where the column is NULL are read by the first query
+
+	t, err := rdb.Read(url, rdb.Table("events"), rdb.Partition("Id", 4))
+*/
+func Partition(column string, parts int) Partition_ {
+	return Partition_{column, parts}
+}
+
+/*
+Watermark keeps high-watermark value of incremental reads between runs,
+the value is int64 or time.Time, Get returns nil if nothing was read yet
+*/
+type Watermark interface {
+	Get() (interface{}, error)
+	Set(interface{}) error
+}
+
+type Incremental_ struct {
+	column    string
+	watermark Watermark
+	pending   *pendingWatermark
+}
+
+// pendingWatermark is the value of the stream read to the end which is not saved yet
+type pendingWatermark struct {
+	mu    sync.Mutex
+	value interface{}
+}
+
+/*
+Incremental reads only rows where the integer or timestamp column is greater than the watermark,
+it can be combined with Partition option.
+The new watermark is saved only when rows are committed by the sink wrapped with Commit,
+so rows are read again on the next run if the sink fails
+
+	wm := rdb.Incremental("Id", rdb.WatermarkFile("/var/lib/export/events.wm"))
+	err := rdb.Source(url, rdb.Table("events"), wm).Drain(wm.Commit(sink))
+
+Read saves the watermark when the table is collected without errors.
+*/
+func Incremental(column string, watermark Watermark) Incremental_ {
+	return Incremental_{column, watermark, &pendingWatermark{}}
+}
+
+/*
+Commit wraps sink saving the watermark of the stream read to the end after the sink commits
+*/
+func (inc Incremental_) Commit(sink tables.Sink) tables.Sink {
+	return func(v reflect.Value) (err error) {
+		if err = sink(v); err == nil && v.Kind() == reflect.Bool && v.Bool() {
+			err = inc.Save()
+		}
+		return
+	}
+}
+
+/*
+Save saves the watermark of the last stream read to the end,
+it does nothing if the stream was not read to the end or the watermark is already saved
+*/
+func (inc Incremental_) Save() (err error) {
+	if inc.pending == nil {
+		return
+	}
+	inc.pending.mu.Lock()
+	defer inc.pending.mu.Unlock()
+	if inc.pending.value != nil {
+		if err = inc.watermark.Set(inc.pending.value); err == nil {
+			inc.pending.value = nil
+		}
+	}
+	return
+}
+
+func (inc Incremental_) keep(v interface{}) {
+	inc.pending.mu.Lock()
+	inc.pending.value = v
+	inc.pending.mu.Unlock()
+}
+
+type watermarkFile string
+
+/*
+WatermarkFile stores watermark value as text in the file
+*/
+func WatermarkFile(path string) Watermark {
+	return watermarkFile(path)
+}
+
+func (f watermarkFile) Get() (interface{}, error) {
+	b, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, zorros.Wrapf(err, "failed to read watermark: %s", err.Error())
+	}
+	return parseWatermark(strings.TrimSpace(string(b)))
+}
+
+func (f watermarkFile) Set(v interface{}) (err error) {
+	var s string
+	switch x := v.(type) {
+	case int64:
+		s = strconv.FormatInt(x, 10)
+	case time.Time:
+		s = x.UTC().Format(time.RFC3339Nano)
+	default:
+		return zorros.Errorf("unsupported watermark value %v", v)
+	}
+	// the file is replaced atomically to keep previous value on failure
+	tmp := string(f) + ".tmp"
+	if err = ioutil.WriteFile(tmp, []byte(s), 0644); err == nil {
+		err = os.Rename(tmp, string(f))
+	}
+	if err != nil {
+		return zorros.Wrapf(err, "failed to write watermark: %s", err.Error())
+	}
+	return
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+/*
+parseWatermark converts value scanned from database or read from file to int64 or time.Time
+*/
+func parseWatermark(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case int64, time.Time:
+		return x, nil
+	case []byte:
+		return parseWatermark(string(x))
+	case string:
+		if i, err := strconv.ParseInt(x, 10, 64); err == nil {
+			return i, nil
+		}
+		for _, l := range timeLayouts {
+			if t, err := time.Parse(l, x); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return nil, zorros.Errorf("value %v is neither integer nor timestamp", v)
+}
+
+func greaterWatermark(a, b interface{}) bool {
+	switch x := a.(type) {
+	case int64:
+		return x > b.(int64)
+	case time.Time:
+		return x.After(b.(time.Time))
+	}
+	return false
+}
+
+type watermarkTracker struct {
+	column string
+	value  interface{}
+	mu     sync.Mutex
+}
+
+func (t *watermarkTracker) index(names []string) int {
+	for i, n := range names {
+		if strings.EqualFold(n, t.column) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *watermarkTracker) update(v reflect.Value) error {
+	var w interface{}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		w = int64(v.Uint())
+	default:
+		if v.Type() != fu.Ts {
+			return zorros.Errorf("column %v of type %v can't be used as watermark", t.column, v.Type())
+		}
+		w = v.Interface()
+	}
+	t.mu.Lock()
+	if t.value == nil || greaterWatermark(w, t.value) {
+		t.value = w
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+type whereClause struct {
+	d     Dialect
+	conds []string
+	args  []interface{}
+}
+
+/*
+add appends condition, %s verbs of format are replaced by placeholders of args
+*/
+func (w *whereClause) add(format string, args ...interface{}) {
+	ph := make([]interface{}, len(args))
+	for i := range args {
+		ph[i] = w.d.Placeholder(len(w.args) + i + 1)
+	}
+	w.conds = append(w.conds, "("+fmt.Sprintf(format, ph...)+")")
+	w.args = append(w.args, args...)
+}
+
+func (w whereClause) clone() *whereClause {
+	return &whereClause{w.d, append([]string{}, w.conds...), append([]interface{}{}, w.args...)}
+}
+
+func (w whereClause) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " where " + strings.Join(w.conds, " and ")
+}
+
+func isPartitioned(opts []interface{}) bool {
+	return fu.Option(Partition_{}, opts).Interface().(Partition_).column != "" ||
+		fu.Option(Incremental_{}, opts).Interface().(Incremental_).column != ""
+}
+
+func partitioned(db *sql.DB, d Dialect, table string, meta map[string]string, cls io.Closer, opts []interface{}) lazy.Stream {
+	from := " from " + d.Table(fu.StrOption(Schema(""), opts), table)
+	part := fu.Option(Partition_{}, opts).Interface().(Partition_)
+	inc := fu.Option(Incremental_{}, opts).Interface().(Incremental_)
+	base := &whereClause{d: d}
+
+	var track *watermarkTracker
+	if inc.column != "" {
+		last, err := inc.watermark.Get()
+		if err != nil {
+			cls.Close()
+			return lazy.Error(err)
+		}
+		// starting from the last value, the watermark never goes back
+		track = &watermarkTracker{column: inc.column, value: last}
+		if last != nil {
+			base.add(escapeVerbs(d.Quote(inc.column))+" > %s", last)
+		}
+	}
+
+	var bounds []interface{}
+	if part.column != "" && part.parts > 1 {
+		lo, hi, err := keyRange(db, d, from, part.column, base)
+		if err != nil {
+			cls.Close()
+			return lazy.Error(err)
+		}
+		bounds = splitRange(lo, hi, part.parts)
+	}
+
+	col := escapeVerbs(d.Quote(part.column))
+	parts := make([]func() lazy.Stream, len(bounds)+1)
+	for i := range parts {
+		w := base.clone()
+		if i > 0 {
+			w.add(col+" >= %s", bounds[i-1])
+		}
+		if i == 0 && len(bounds) > 0 {
+			w.add(col+" < %s or "+col+" is null", bounds[i])
+		} else if i < len(bounds) {
+			w.add(col+" < %s", bounds[i])
+		}
+		query := "select *" + from + w.String()
+		parts[i] = func() lazy.Stream {
+			return queryStream(db, d, query, w.args, meta, track, iokit.CloserChain{}, opts)
+		}
+	}
+
+	return mergeStreams(parts, cls, func() error {
+		// the watermark is saved when sink commits rows
+		if track != nil && track.value != nil {
+			inc.keep(track.value)
+		}
+		return nil
+	})
+}
+
+func escapeVerbs(s string) string {
+	return strings.Replace(s, "%", "%%", -1)
+}
+
+func keyRange(db *sql.DB, d Dialect, from, column string, w *whereClause) (lo, hi interface{}, err error) {
+	c := d.Quote(column)
+	var a, b interface{}
+	if err = db.QueryRow("select min("+c+"), max("+c+")"+from+w.String(), w.args...).Scan(&a, &b); err != nil {
+		return nil, nil, zorros.Wrapf(err, "query error: %s", err.Error())
+	}
+	if lo, err = parseWatermark(a); err == nil {
+		hi, err = parseWatermark(b)
+	}
+	return
+}
+
+/*
+splitRange returns increasing inner boundaries of N equal ranges between lo and hi,
+there are less boundaries if the range is too narrow
+*/
+func splitRange(lo, hi interface{}, n int) (bounds []interface{}) {
+	switch a := lo.(type) {
+	case int64:
+		b := hi.(int64)
+		prev := a
+		for i := 1; i < n; i++ {
+			x := a + int64(float64(b-a)*float64(i)/float64(n))
+			if x > prev {
+				bounds = append(bounds, x)
+				prev = x
+			}
+		}
+	case time.Time:
+		b := hi.(time.Time)
+		prev := a
+		for i := 1; i < n; i++ {
+			x := a.Add(time.Duration(float64(b.Sub(a)) * float64(i) / float64(n)))
+			if x.After(prev) {
+				bounds = append(bounds, x)
+				prev = x
+			}
+		}
+	}
+	return
+}
+
+/*
+mergeStreams reads streams concurrently and returns their rows in order of arrival,
+end is called when all streams are read without errors
+*/
+func mergeStreams(parts []func() lazy.Stream, cls io.Closer, end func() error) lazy.Stream {
+	type C struct {
+		reflect.Value
+		error
+	}
+	c := make(chan C)
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for _, p := range parts {
+		wg.Add(1)
+		go func(p func() lazy.Stream) {
+			defer wg.Done()
+			z := p()
+			defer z(lazy.STOP)
+			for i := uint64(0); ; i++ {
+				v, err := z(i)
+				if err == nil && v.Kind() == reflect.Bool {
+					if v.Bool() {
+						continue
+					}
+					return
+				}
+				select {
+				case c <- C{v, err}:
+					if err != nil {
+						return
+					}
+				case <-stop:
+					return
+				}
+			}
+		}(p)
+	}
+	go func() {
+		wg.Wait()
+		close(c)
+	}()
+
+	wc := fu.WaitCounter{Value: 0}
+	f := fu.AtomicFlag{Value: 0}
+	finish := func() {
+		if f.Set() {
+			close(stop)
+			wg.Wait()
+			cls.Close()
+		}
+	}
+
+	return func(index uint64) (reflect.Value, error) {
+		if index == lazy.STOP {
+			wc.Stop()
+			finish()
+			return fu.False, nil
+		}
+		if wc.Wait(index) {
+			x, ok := <-c
+			if ok && x.error == nil {
+				wc.Inc()
+				return x.Value, nil
+			}
+			wc.Stop()
+			if ok {
+				return fu.False, x.error
+			}
+			finish()
+			return fu.False, end()
+		}
+		return fu.False, nil
+	}
+}
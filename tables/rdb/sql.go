@@ -1,8 +1,8 @@
 package rdb
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"go4ml.xyz/base/fu"
 	"go4ml.xyz/base/fu/lazy"
 	"go4ml.xyz/base/tables"
@@ -16,8 +16,11 @@ import (
 	//	_ "github.com/mattn/go-sqlite3"
 )
 
-func Read(source interface{}, opts ...interface{}) (*tables.Table, error) {
-	return Source(source, opts...).Collect()
+func Read(source interface{}, opts ...interface{}) (t *tables.Table, err error) {
+	if t, err = Source(source, opts...).Collect(); err == nil {
+		err = fu.Option(Incremental_{}, opts).Interface().(Incremental_).Save()
+	}
+	return
 }
 
 func Write(source interface{}, t *tables.Table, opts ...interface{}) error {
@@ -48,169 +51,321 @@ func Source(source interface{}, opts ...interface{}) tables.Lazy {
 			cls = db
 		}
 		if err != nil {
-			tables.SourceError(zorros.Wrapf(err, "database connection error: %s", err.Error()))
+			return lazy.Error(zorros.Wrapf(err, "database connection error: %s", err.Error()))
 		}
-		drv := fu.StrOption(Driver(""), opts)
+		d := dialectOf(opts)
 		schema := fu.StrOption(Schema(""), opts)
-		if schema != "" {
-			switch drv {
-			case "mysql":
-				_, err = db.Exec("use " + schema)
-			case "postgres":
-				_, err = db.Exec("set search_path to " + schema)
+		query := fu.StrOption(Query(""), opts)
+		table := fu.StrOption(Table(""), opts)
+		meta := map[string]string{}
+		if table != "" {
+			// database types are more precise than reported by the driver,
+			// but if metadata is unavailable the driver types are used
+			if cols, err := d.Columns(db, schema, table); err == nil {
+				for _, c := range cols {
+					meta[c.Name] = c.SqlType
+				}
 			}
 		}
-		if err != nil {
-			cls.Close()
-			return lazy.Error(zorros.Wrapf(err, "query error: %s", err.Error()))
+		if isPartitioned(opts) {
+			if query != "" || table == "" {
+				cls.Close()
+				return lazy.Error(zorros.Errorf("partitioned and incremental reads require rdb.Table option"))
+			}
+			return partitioned(db, d, table, meta, cls, opts)
 		}
-		query := fu.StrOption(Query(""), opts)
 		if query == "" {
-			table := fu.StrOption(Table(""), opts)
 			if table != "" {
-				query = "select * from " + table
+				query = "select * from " + d.Table(schema, table)
 			} else {
 				panic("there is no query or table")
 			}
 		}
-		rows, err := db.Query(query)
-		if err != nil {
-			cls.Close()
-			return lazy.Error(zorros.Wrapf(err, "query error: %s", err.Error()))
-		}
-		cls = iokit.CloserChain{rows, cls}
-		tps, err := rows.ColumnTypes()
-		if err != nil {
-			cls.Close()
-			return lazy.Error(zorros.Wrapf(err, "get types error: %s", err.Error()))
+		return queryStream(db, d, query, nil, meta, nil, cls, opts)
+	}
+}
+
+/*
+queryStream executes query and streams rows, cls is closed when stream is stopped or finished,
+track is called for every row if it's not nil
+*/
+func queryStream(db *sql.DB, d Dialect, query string, args []interface{}, meta map[string]string, track *watermarkTracker, cls io.Closer, opts []interface{}) lazy.Stream {
+	rows, conn, err := queryWithSchema(db, d, fu.StrOption(Schema(""), opts), query, args...)
+	if conn != nil {
+		cls = iokit.CloserChain{conn, cls}
+	}
+	if err != nil {
+		cls.Close()
+		return lazy.Error(zorros.Wrapf(err, "query error: %s", err.Error()))
+	}
+	cls = iokit.CloserChain{rows, cls}
+	tps, err := rows.ColumnTypes()
+	if err != nil {
+		cls.Close()
+		return lazy.Error(zorros.Wrapf(err, "get types error: %s", err.Error()))
+	}
+	ns, err := rows.Columns()
+	if err != nil {
+		cls.Close()
+		return lazy.Error(zorros.Wrapf(err, "get names error: %s", err.Error()))
+	}
+	x := make([]interface{}, len(ns))
+	describe, err := Describe(ns, opts)
+	if err != nil {
+		cls.Close()
+		return lazy.Error(err)
+	}
+	names := make([]string, len(ns))
+	for i, n := range ns {
+		colType, colName, _ := describe(n)
+		if colType == "" {
+			if colType = meta[n]; colType == "" {
+				colType = tps[i].DatabaseTypeName()
+			}
 		}
-		ns, err := rows.Columns()
-		if err != nil {
+		s, ok := scannerOf(colType)
+		if !ok {
 			cls.Close()
-			return lazy.Error(zorros.Wrapf(err, "get names error: %s", err.Error()))
+			return lazy.Error(zorros.Errorf("unknown type %v of column %v", colType, n))
 		}
-		x := make([]interface{}, len(ns))
-		describe, err := Describe(ns, opts)
-		if err != nil {
+		x[i] = s
+		names[i] = colName
+	}
+	key := -1
+	if track != nil {
+		if key = track.index(ns); key < 0 {
 			cls.Close()
-			return lazy.Error(err)
-		}
-		names := make([]string, len(ns))
-		for i, n := range ns {
-			var s SqlScan
-			colType, colName, _ := describe(n)
-			if colType != "" {
-				s = scanner(colType)
-			} else {
-				s = scanner(tps[i].DatabaseTypeName())
-			}
-			x[i] = s
-			names[i] = colName
+			return lazy.Error(zorros.Errorf("column %v does not exist", track.column))
 		}
+	}
 
-		wc := fu.WaitCounter{Value: 0}
-		f := fu.AtomicFlag{Value: 0}
+	wc := fu.WaitCounter{Value: 0}
+	f := fu.AtomicFlag{Value: 0}
 
-		return func(index uint64) (reflect.Value, error) {
-			if index == lazy.STOP {
-				wc.Stop()
-				return reflect.ValueOf(false), nil
+	return func(index uint64) (reflect.Value, error) {
+		if index == lazy.STOP {
+			wc.Stop()
+			if f.Set() {
+				cls.Close()
 			}
-			if wc.Wait(index) {
-				end := !rows.Next()
-				if !end {
-					rows.Scan(x...)
-					lr := fu.Struct{Names: names, Columns: make([]reflect.Value, len(ns))}
-					for i := range x {
-						y := x[i].(SqlScan)
-						v, ok := y.Value()
-						if !ok {
-							lr.Na.Set(i, true)
-						}
-						lr.Columns[i] = v
+			return reflect.ValueOf(false), nil
+		}
+		if wc.Wait(index) {
+			end := !rows.Next()
+			if !end {
+				if err := rows.Scan(x...); err != nil {
+					wc.Stop()
+					return reflect.ValueOf(false), zorros.Wrapf(err, "scan error: %s", err.Error())
+				}
+				lr := fu.Struct{Names: names, Columns: make([]reflect.Value, len(ns))}
+				for i := range x {
+					y := x[i].(SqlScan)
+					v, ok := y.Value()
+					if !ok {
+						lr.Na.Set(i, true)
 					}
-					wc.Inc()
-					return reflect.ValueOf(lr), nil
+					lr.Columns[i] = v
 				}
-				wc.Stop()
+				if key >= 0 && !lr.Na.Bit(key) {
+					if err := track.update(lr.Columns[key]); err != nil {
+						wc.Stop()
+						return reflect.ValueOf(false), err
+					}
+				}
+				wc.Inc()
+				return reflect.ValueOf(lr), nil
 			}
-			if f.Set() {
-				cls.Close()
+			wc.Stop()
+			if err := rows.Err(); err != nil {
+				return reflect.ValueOf(false), zorros.Wrapf(err, "query error: %s", err.Error())
 			}
-			return reflect.ValueOf(false), nil
 		}
+		if f.Set() {
+			cls.Close()
+		}
+		return reflect.ValueOf(false), nil
 	}
 }
 
+/*
+queryWithSchema executes query on dedicated connection when schema has to be selected,
+because selecting of schema affects only one connection from the pool
+*/
+func queryWithSchema(db *sql.DB, d Dialect, schema, query string, args ...interface{}) (rows *sql.Rows, conn *sql.Conn, err error) {
+	stmt := ""
+	if schema != "" {
+		stmt = d.SchemaStmt(schema)
+	}
+	if stmt == "" {
+		rows, err = db.Query(query, args...)
+		return
+	}
+	ctx := context.Background()
+	if conn, err = db.Conn(ctx); err != nil {
+		return
+	}
+	if _, err = conn.ExecContext(ctx, stmt); err != nil {
+		return
+	}
+	rows, err = conn.QueryContext(ctx, query, args...)
+	return
+}
+
 func splitDriver(url string) (string, string) {
 	q := strings.SplitN(url, ":", 2)
 	return q[0], q[1]
 }
 
-func scanner(q string) SqlScan {
-	switch q {
-	case "VARCHAR", "TEXT", "CHAR", "STRING":
-		return &SqlString{}
-	case "INT8", "SMALLINT", "INT2":
-		return &SqlSmall{}
-	case "INTEGER", "INT", "INT4":
-		return &SqlInteger{}
-	case "BIGINT":
-		return &SqlBigint{}
-	case "BOOLEAN":
-		return &SqlBool{}
-	case "DECIMAL", "NUMERIC", "REAL", "DOUBLE", "FLOAT8":
-		return &SqlDouble{}
-	case "FLOAT", "FLOAT4":
-		return &SqlFloat{}
-	case "DATE", "DATETIME", "TIMESTAMP":
-		return &SqlTimestamp{}
-	default:
-		if strings.Index(q, "VARCHAR(") == 0 ||
-			strings.Index(q, "CHAR(") == 0 {
-			return &SqlString{}
+/*
+scannerOf returns scanner for SQL type reported by database or declared by column option,
+it ignores type parameters like length and precision
+*/
+func scannerOf(q string) (SqlScan, bool) {
+	q = strings.ToUpper(strings.TrimSpace(q))
+	unsigned := strings.Contains(q, "UNSIGNED")
+	if unsigned {
+		q = strings.TrimSpace(strings.Replace(q, "UNSIGNED", "", 1))
+	}
+	if j := strings.Index(q, "("); j >= 0 {
+		if k := strings.Index(q, ")"); k > j {
+			q = strings.TrimSpace(q[:j] + q[k+1:])
 		}
-		if strings.Index(q, "DECIMAL(") == 0 ||
-			strings.Index(q, "NUMERIC(") == 0 {
-			return &SqlDouble{}
+	}
+	if unsigned {
+		switch q {
+		case "TINYINT":
+			return &SqlUnsigned{tp: fu.Uint8}, true
+		case "SMALLINT":
+			return &SqlUnsigned{tp: fu.Uint16}, true
+		case "MEDIUMINT", "INT", "INTEGER":
+			return &SqlUnsigned{tp: fu.Uint32}, true
+		case "BIGINT":
+			return &SqlUnsigned{tp: fu.Uint64}, true
 		}
 	}
-	panic("unknown column type " + q)
+	switch q {
+	case "VARCHAR", "TEXT", "CHAR", "STRING", "CHARACTER", "CHARACTER VARYING", "NCHAR", "NVARCHAR", "BPCHAR",
+		"TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "CLOB", "JSON", "UUID":
+		return &SqlString{}, true
+	case "TINYINT":
+		return &SqlTiny{}, true
+	case "SMALLINT", "INT2":
+		return &SqlSmall{}, true
+	case "INTEGER", "INT", "INT4", "MEDIUMINT":
+		return &SqlInteger{}, true
+	case "BIGINT", "INT8":
+		return &SqlBigint{}, true
+	case "BOOLEAN", "BOOL":
+		return &SqlBool{}, true
+	case "DECIMAL", "NUMERIC", "REAL", "DOUBLE", "DOUBLE PRECISION", "FLOAT8":
+		return &SqlDouble{}, true
+	case "FLOAT", "FLOAT4":
+		return &SqlFloat{}, true
+	case "DATE", "DATETIME", "TIMESTAMP", "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITHOUT TIME ZONE":
+		return &SqlTimestamp{}, true
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA", "BINARY", "VARBINARY":
+		return &SqlBytes{}, true
+	}
+	return nil, false
 }
 
 func batchInsertStmt(tx *sql.Tx, names []string, pk []bool, lines int, table string, opts []interface{}) (stmt *sql.Stmt, err error) {
-	drv := fu.StrOption(Driver(""), opts)
+	d := dialectOf(opts)
 	ifExists := fu.Option(ErrorIfExists, opts).Interface().(IfExists_)
 	L := len(names)
-	q1 := " values "
-	for j := 0; j < lines; j++ {
-		q1 += "("
-		if drv == "postgres" {
-			for k := range names {
-				q1 += fmt.Sprintf("$%d,", j*L+k+1)
-			}
-		} else {
-			q1 += strings.Repeat("?,", L)
+	values := make([]string, lines)
+	for j := range values {
+		q := make([]string, L)
+		for k := range q {
+			q[k] = d.Placeholder(j*L + k + 1)
 		}
-		q1 = q1[:len(q1)-1] + "),"
+		values[j] = "(" + strings.Join(q, ",") + ")"
 	}
-	q := "insert into " + table + "(" + strings.Join(names, ",") + ")" + q1[:len(q1)-1]
+	quoted := quoteAll(d, names)
+	q := "insert into " + table + "(" + strings.Join(quoted, ",") + ") values " + strings.Join(values, ",")
 
 	if ifExists == InsertUpdateIfExists {
-		if len(pk) > 0 {
-			q += " on duplicate key update "
-			for i, n := range names {
-				if !pk[i] {
-					q += " " + n + " = values(" + n + "),"
-				}
-			}
-			q = q[:len(q)-1]
-		}
+		q += d.Upsert(quoted, pk)
 	}
 	stmt, err = tx.Prepare(q)
 	return
 }
 
+/*
+tableColumns returns columns of existing (quoted) table or nil if table does not exist
+*/
+func tableColumns(db *sql.DB, table string) []*sql.ColumnType {
+	rows, err := db.Query("select * from " + table + " where 1=0")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	tps, err := rows.ColumnTypes()
+	if err != nil {
+		return nil
+	}
+	return tps
+}
+
+func typeFamily(tp reflect.Type) int {
+	switch tp.Kind() {
+	case reflect.String:
+		return 1
+	case reflect.Bool:
+		return 2
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return 3
+	case reflect.Float32, reflect.Float64:
+		return 4
+	}
+	if tp == fu.Ts {
+		return 5
+	}
+	return 0
+}
+
+/*
+compatibleColumns checks that all stream columns exist in the table and can be stored there
+*/
+func compatibleColumns(existing []*sql.ColumnType, names []string, types []reflect.Type) error {
+	for i, n := range names {
+		var c *sql.ColumnType
+		for _, x := range existing {
+			if strings.EqualFold(x.Name(), n) {
+				c = x
+				break
+			}
+		}
+		if c == nil {
+			return zorros.Errorf("table does not have column %v", n)
+		}
+		if s, ok := scannerOf(c.DatabaseTypeName()); ok {
+			a, b := typeFamily(s.Reflect()), typeFamily(types[i])
+			// integers can be stored as floats and booleans as integers
+			if a != b && a != 0 && b != 0 && !(a == 4 && b == 3) && !(a == 3 && b == 2) {
+				return zorros.Errorf("column %v of type %v is not compatible with %v", n, c.DatabaseTypeName(), types[i])
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Sink writes stream into the table
+
+	rdb.Sink("sqlite3:file:/tmp/test.db",
+		rdb.Table("maxd"),
+		rdb.InsertUpdateIfExists,
+		rdb.CommitEvery(100000),
+		rdb.VARCHAR("Id").PrimaryKey(),
+		rdb.DECIMAL("Target", 2))
+
+ErrorIfExists and DropIfExists create new table. AppendIfExists inserts rows
+into existing table if it has compatible columns, and InsertUpdateIfExists
+upserts rows keyed on PrimaryKey() columns. CommitEvery(n) commits transaction
+every n rows, so rows committed before a failure stay in the table.
+*/
 func Sink(source interface{}, opts ...interface{}) tables.Sink {
 	db, opts, err := connectDB(source, opts)
 	cls := io.Closer(iokit.CloserChain{})
@@ -220,20 +375,18 @@ func Sink(source interface{}, opts ...interface{}) tables.Sink {
 	if err != nil {
 		return tables.SinkError(zorros.Errorf("database connection error: %w", err))
 	}
-	drv := fu.StrOption(Driver(""), opts)
+	d := dialectOf(opts)
 
-	schema := fu.StrOption(Schema(""), opts)
-	if schema != "" {
-		switch drv {
-		case "mysql":
-			_, err = db.Exec("use " + schema)
-		case "postgres":
-			_, err = db.Exec("set search_path to " + schema)
-		}
+	table := fu.StrOption(Table(""), opts)
+	if table == "" {
+		panic("there is no table")
 	}
-	if err != nil {
-		cls.Close()
-		return tables.SinkError(zorros.Wrapf(err, "query error: %s", err.Error()))
+	table = d.Table(fu.StrOption(Schema(""), opts), table)
+
+	ifExists := fu.Option(ErrorIfExists, opts).Interface().(IfExists_)
+	var existing []*sql.ColumnType
+	if ifExists == AppendIfExists || ifExists == InsertUpdateIfExists {
+		existing = tableColumns(db, table)
 	}
 
 	tx, err := db.Begin()
@@ -242,96 +395,130 @@ func Sink(source interface{}, opts ...interface{}) tables.Sink {
 		return tables.SinkError(zorros.Wrapf(err, "database begin transaction error: %s", err.Error()))
 	}
 
-	table := fu.StrOption(Table(""), opts)
-	if table == "" {
-		panic("there is no table")
-	}
-	if fu.Option(ErrorIfExists, opts).Interface().(IfExists_) == DropIfExists {
-		_, err := tx.Exec(sqlDropQuery(table, opts...))
+	if ifExists == DropIfExists {
+		_, err := tx.Exec("drop table if exists " + table)
 		if err != nil {
+			tx.Rollback()
 			cls.Close()
 			return tables.SinkError(zorros.Wrapf(err, "drop table error: %s", err.Error()))
 		}
 	}
 
 	batchLen := fu.IntOption(Batch(1), opts)
+	commitEvery := fu.IntOption(CommitEvery(0), opts)
 	var stmt *sql.Stmt
 	created := false
+	count := 0
 	batch := []interface{}{}
 	names := []string{}
 	pk := []bool{}
+
+	exec := func() (err error) {
+		lines := len(batch) / len(names)
+		s := stmt
+		if s == nil || lines != batchLen {
+			if s, err = batchInsertStmt(tx, names, pk, lines, table, opts); err != nil {
+				return
+			}
+			cls = iokit.CloserChain{s, cls}
+			if lines == batchLen {
+				stmt = s
+			}
+		}
+		if _, err = s.Exec(batch...); err == nil {
+			batch = batch[:0]
+		}
+		return
+	}
+
+	commit := func() (err error) {
+		if len(batch) > 0 {
+			if err = exec(); err != nil {
+				return
+			}
+		}
+		// statements prepared in transaction are closed by commit
+		stmt = nil
+		return tx.Commit()
+	}
+
 	return func(val reflect.Value) (err error) {
-		var describe func(int) (string, string, bool)
 		if val.Kind() == reflect.Bool {
 			if val.Bool() {
-				if len(batch) > 0 {
-					if stmt, err = batchInsertStmt(tx, names, pk, len(batch)/len(names), table, opts); err == nil {
-						if _, err = stmt.Exec(batch...); err == nil {
-							cls = iokit.CloserChain{stmt, cls}
-						}
-					}
-				}
-				if err == nil {
-					err = tx.Commit()
-				}
+				err = commit()
+			} else {
+				tx.Rollback()
 			}
 			cls.Close()
 			return
 		}
 		lr := val.Interface().(fu.Struct)
-		names = make([]string, len(lr.Names))
-		pk = make([]bool, len(lr.Names))
-		drv := fu.StrOption(Driver(""), opts)
-		dsx, err := Describe(lr.Names, opts)
-		if err != nil {
-			cls.Close()
-			return
-		}
-		describe = func(i int) (colType, colName string, isPk bool) {
-			v := lr.Names[i]
-			colType, colName, isPk = dsx(v)
-			if colType == "" {
-				colType = sqlTypeOf(lr.Columns[i].Type(), drv)
-			}
-			return
-		}
-		for i := range names {
-			_, names[i], pk[i] = describe(i)
-		}
 		if !created {
-			_, err = tx.Exec(sqlCreateQuery(lr, table, describe, opts))
+			names = make([]string, len(lr.Names))
+			pk = make([]bool, len(lr.Names))
+			dsx, err := Describe(lr.Names, opts)
 			if err != nil {
-				cls.Close()
+				return err
+			}
+			describe := func(i int) (colType, colName string, isPk bool) {
+				v := lr.Names[i]
+				colType, colName, isPk = dsx(v)
+				if colType == "" {
+					colType = d.TypeOf(lr.Columns[i].Type())
+				}
+				return
+			}
+			hasPk := false
+			for i := range names {
+				_, names[i], pk[i] = describe(i)
+				hasPk = hasPk || pk[i]
+			}
+			if ifExists == InsertUpdateIfExists && !hasPk {
+				return zorros.Errorf("upsert into %v requires primary key columns", table)
+			}
+			if existing != nil {
+				types := make([]reflect.Type, len(lr.Columns))
+				for i, c := range lr.Columns {
+					types[i] = c.Type()
+				}
+				if err = compatibleColumns(existing, names, types); err != nil {
+					return err
+				}
+			} else if _, err = tx.Exec(sqlCreateQuery(lr, table, describe, d, opts)); err != nil {
 				return zorros.Wrapf(err, "create table error: %s", err.Error())
 			}
 			created = true
 		}
 		if len(batch)/len(names) >= batchLen {
-			if stmt == nil {
-				stmt, err = batchInsertStmt(tx, names, pk, len(batch)/len(names), table, opts)
-				if err != nil {
-					return err
-				}
-				cls = iokit.CloserChain{stmt, cls}
-			}
-			_, err = stmt.Exec(batch...)
-			if err != nil {
-				return err
+			if err = exec(); err != nil {
+				return
 			}
-			batch = batch[:0]
 		}
 		for i := range lr.Names {
 			if lr.Na.Bit(i) {
 				batch = append(batch, nil)
 			} else {
-				batch = append(batch, lr.Columns[i].Interface())
+				v, err := d.Value(lr.Columns[i])
+				if err != nil {
+					return err
+				}
+				batch = append(batch, v)
+			}
+		}
+		count++
+		if commitEvery > 0 && count%commitEvery == 0 {
+			if err = commit(); err != nil {
+				return
+			}
+			if tx, err = db.Begin(); err != nil {
+				return zorros.Wrapf(err, "database begin transaction error: %s", err.Error())
 			}
 		}
 		return
 	}
 }
 
-func sqlCreateQuery(lr fu.Struct, table string, describe func(int) (string, string, bool), opts []interface{}) string {
+func sqlCreateQuery(lr fu.Struct, table string, describe func(int) (string, string, bool), d Dialect, opts []interface{}) string {
 	pk := []string{}
 	query := "create table "
 
@@ -346,9 +533,9 @@ func sqlCreateQuery(lr fu.Struct, table string, describe func(int) (string, stri
 			query += ", "
 		}
 		colType, colName, isPK := describe(i)
-		query = query + colName + " " + colType
+		query = query + d.Quote(colName) + " " + colType
 		if isPK {
-			pk = append(pk, colName)
+			pk = append(pk, d.Quote(colName))
 		}
 	}
 
@@ -359,44 +546,3 @@ func sqlCreateQuery(lr fu.Struct, table string, describe func(int) (string, stri
 	query += " )"
 	return query
 }
-
-func sqlDropQuery(table string, opts ...interface{}) string {
-	schema := fu.StrOption(Schema(""), opts)
-	if schema != "" {
-		schema = schema + "."
-	}
-	return "drop table if exists " + schema + table
-}
-
-func sqlTypeOf(tp reflect.Type, driver string) string {
-	switch tp.Kind() {
-	case reflect.String:
-		if driver == "postgres" {
-			return "VARCHAR(65535)" /* redshift TEXT == VARCHAR(256) */
-		}
-		return "TEXT"
-	case reflect.Int8, reflect.Uint8, reflect.Int16:
-		return "SMALLINT"
-	case reflect.Uint16, reflect.Int32, reflect.Int:
-		return "INTEGER"
-	case reflect.Uint, reflect.Uint32, reflect.Int64, reflect.Uint64:
-		return "BIGINT"
-	case reflect.Float32:
-		if driver == "postgres" {
-			return "REAL" /* redshift does not FLOAT */
-		}
-		return "FLOAT"
-	case reflect.Float64:
-		if driver == "postgres" {
-			return "DOUBLE PRECISION" /* redshift does not have DOUBLE */
-		}
-		return "DOUBLE"
-	case reflect.Bool:
-		return "BOOLEAN"
-	default:
-		if tp == fu.Ts {
-			return "DATETIME"
-		}
-	}
-	panic("unsupported data type " + fmt.Sprintf("%v %v", tp.String(), tp.Kind()))
-}
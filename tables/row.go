@@ -24,14 +24,16 @@ FillRow fills row as a struct
 */
 func (t *Table) FillRow(i int, tp reflect.Type, p reflect.Value) {
 	v := p.Elem()
-	fl := tp.NumField()
-	for fi := 0; fi < fl; fi++ {
-		n := tp.Field(fi).Name
-		j := fu.IndexOf(n, t.raw.Names)
+	for _, f := range fu.BoundFields(tp) {
+		j := fu.IndexOf(f.Name, t.raw.Names)
 		if j < 0 {
-			panic("table does not have field " + n)
+			if f.OmitEmpty {
+				fu.SetField(v.FieldByIndex(f.Index), reflect.Value{}, true)
+				continue
+			}
+			panic("table does not have field " + f.Name)
 		}
-		v.Field(fi).Set(t.raw.Columns[j].Index(i))
+		fu.SetField(v.FieldByIndex(f.Index), t.raw.Columns[j].Index(i), t.raw.Na[j].Bit(i))
 	}
 }
 
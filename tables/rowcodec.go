@@ -0,0 +1,220 @@
+package tables
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/binary"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/zorros"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+/*
+rowCodec encodes rows of the same columns and types into binary form and decodes them back
+*/
+type rowCodec struct {
+	columns []string
+	types   []reflect.Type
+}
+
+// codecTypes are types of columns which can be restored by name from the header
+var codecTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), "", false, time.Time{}, fu.Tensor{}, fu.Fixed8{}} {
+		t := reflect.TypeOf(v)
+		codecTypes[t.String()] = t
+	}
+}
+
+/*
+newRowCodec creates codec for rows with columns and types of specified row
+*/
+func newRowCodec(lr fu.Struct) (*rowCodec, error) {
+	c := &rowCodec{columns: lr.Names, types: make([]reflect.Type, len(lr.Columns))}
+	for i, v := range lr.Columns {
+		if !v.IsValid() {
+			return nil, zorros.Errorf("column %v does not have type", lr.Names[i])
+		}
+		c.types[i] = v.Type()
+	}
+	return c, nil
+}
+
+/*
+writeHeader writes names and types of columns, so codec can be restored by readRowCodec
+*/
+func (c *rowCodec) writeHeader(wr *bufio.Writer) (err error) {
+	b := make([]byte, binary.MaxVarintLen64)
+	wr.Write(b[:binary.PutUvarint(b, uint64(len(c.types)))])
+	for i, t := range c.types {
+		if _, ok := codecTypes[t.String()]; !ok {
+			return zorros.Errorf("column %v of type %v cannot be written to disk", c.columns[i], t)
+		}
+		writeBytes(wr, []byte(c.columns[i]))
+		writeBytes(wr, []byte(t.String()))
+	}
+	return
+}
+
+func readRowCodec(rd *bufio.Reader) (c *rowCodec, err error) {
+	var n uint64
+	if n, err = binary.ReadUvarint(rd); err != nil {
+		return
+	}
+	c = &rowCodec{columns: make([]string, n), types: make([]reflect.Type, n)}
+	for i := range c.types {
+		var name, tp []byte
+		if name, err = readBytes(rd); err != nil {
+			return
+		}
+		if tp, err = readBytes(rd); err != nil {
+			return
+		}
+		ok := false
+		if c.types[i], ok = codecTypes[string(tp)]; !ok {
+			return nil, zorros.Errorf("column %s has unknown type %s", name, tp)
+		}
+		c.columns[i] = string(name)
+	}
+	return
+}
+
+/*
+encode writes row in compact binary form: NA bits and then values of columns,
+numbers are varints or fixed-size floats, strings and binary values are prefixed by length
+*/
+func (c *rowCodec) encode(wr *bufio.Writer, lr fu.Struct) (err error) {
+	if len(lr.Columns) != len(c.types) {
+		return zorros.Errorf("row has %d columns but %d expected", len(lr.Columns), len(c.types))
+	}
+	na := make([]byte, (len(c.types)+7)/8)
+	for i := range c.types {
+		if lr.Na.Bit(i) {
+			na[i/8] |= 1 << (i % 8)
+		}
+	}
+	wr.Write(na)
+	b := make([]byte, binary.MaxVarintLen64)
+	for i, t := range c.types {
+		v := lr.Columns[i]
+		if !v.IsValid() {
+			v = reflect.Zero(t)
+		} else if v.Type() != t {
+			return zorros.Errorf("column %v has type %v but %v expected", c.columns[i], v.Type(), t)
+		}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			wr.Write(b[:binary.PutVarint(b, v.Int())])
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			wr.Write(b[:binary.PutUvarint(b, v.Uint())])
+		case reflect.Float32:
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v.Float())))
+			wr.Write(b[:4])
+		case reflect.Float64:
+			binary.LittleEndian.PutUint64(b, math.Float64bits(v.Float()))
+			wr.Write(b[:8])
+		case reflect.Bool:
+			wr.WriteByte(fu.Ife(v.Bool(), byte(1), byte(0)).(byte))
+		case reflect.String:
+			wr.Write(b[:binary.PutUvarint(b, uint64(len(v.String())))])
+			wr.WriteString(v.String())
+		default:
+			if t == fu.Fixed8Type {
+				err = wr.WriteByte(byte(v.Interface().(fu.Fixed8).Raw()))
+				break
+			}
+			m, ok := v.Interface().(encoding.BinaryMarshaler)
+			if !ok {
+				return zorros.Errorf("column %v of type %v cannot be written to disk", c.columns[i], t)
+			}
+			var bs []byte
+			if bs, err = m.MarshalBinary(); err != nil {
+				return zorros.Trace(err)
+			}
+			wr.Write(b[:binary.PutUvarint(b, uint64(len(bs)))])
+			_, err = wr.Write(bs)
+		}
+	}
+	return
+}
+
+func (c *rowCodec) decode(rd *bufio.Reader) (lr fu.Struct, err error) {
+	na := make([]byte, (len(c.types)+7)/8)
+	if _, err = io.ReadFull(rd, na); err != nil {
+		return
+	}
+	lr = fu.Struct{Names: c.columns, Columns: make([]reflect.Value, len(c.types))}
+	b := make([]byte, 8)
+	for i, t := range c.types {
+		if na[i/8]&(1<<(i%8)) != 0 {
+			lr.Na.Set(i, true)
+		}
+		v := reflect.New(t).Elem()
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var x int64
+			x, err = binary.ReadVarint(rd)
+			v.SetInt(x)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var x uint64
+			x, err = binary.ReadUvarint(rd)
+			v.SetUint(x)
+		case reflect.Float32:
+			if _, err = io.ReadFull(rd, b[:4]); err == nil {
+				v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+			}
+		case reflect.Float64:
+			if _, err = io.ReadFull(rd, b[:8]); err == nil {
+				v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+			}
+		case reflect.Bool:
+			var x byte
+			x, err = rd.ReadByte()
+			v.SetBool(x != 0)
+		default:
+			if t == fu.Fixed8Type {
+				var x byte
+				x, err = rd.ReadByte()
+				v.Set(reflect.ValueOf(fu.RawAsFixed8(int8(x))))
+				break
+			}
+			var bs []byte
+			if bs, err = readBytes(rd); err != nil {
+				break
+			}
+			if t.Kind() == reflect.String {
+				v.SetString(string(bs))
+			} else {
+				err = v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(bs)
+			}
+		}
+		if err != nil {
+			return lr, zorros.Wrapf(err, "failed to read spilled row: %s", err.Error())
+		}
+		lr.Columns[i] = v
+	}
+	return
+}
+
+func writeBytes(wr *bufio.Writer, bs []byte) {
+	b := make([]byte, binary.MaxVarintLen64)
+	wr.Write(b[:binary.PutUvarint(b, uint64(len(bs)))])
+	wr.Write(bs)
+}
+
+func readBytes(rd *bufio.Reader) (bs []byte, err error) {
+	var n uint64
+	if n, err = binary.ReadUvarint(rd); err != nil {
+		return
+	}
+	bs = make([]byte, n)
+	_, err = io.ReadFull(rd, bs)
+	return
+}
@@ -0,0 +1,351 @@
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/zorros"
+	"reflect"
+	"strings"
+)
+
+/*
+Field describes one column of schema
+*/
+type Field struct {
+	Name     string
+	Type     reflect.Type
+	Nullable bool    // column can have NA values
+	Enum     Enumset // allowed values of enumerated column, nil if column is not enumerated
+	Dims     []int   // channels, height and width of tensors, nil if they can differ
+}
+
+/*
+Schema describes columns of table or stream, it can be stored as JSON with model
+
+	schema := train.Schema()
+	bs, _ := json.Marshal(schema)
+	...
+	err := csv.Source(iokit.File("test.csv"), ...).Validate(schema).Drain(sink)
+*/
+type Schema struct {
+	Fields []Field
+}
+
+// schemaTypes are types of columns which can be restored from JSON
+var schemaTypes = map[string]reflect.Type{enumType.String(): enumType}
+
+func init() {
+	for k, t := range codecTypes {
+		schemaTypes[k] = t
+	}
+}
+
+/*
+Schema returns schema of table, tensor dims are set only if all tensors have the same dims
+*/
+func (t *Table) Schema() Schema {
+	s := Schema{Fields: make([]Field, len(t.raw.Names))}
+	for i, n := range t.raw.Names {
+		col := t.raw.Columns[i]
+		f := Field{Name: n, Type: col.Type().Elem(), Nullable: t.raw.Na[i].Count() > 0}
+		if f.Type == enumType {
+			f.Enum = Enumset{}
+			for j := 0; j < col.Len(); j++ {
+				if t.raw.Na[i].Bit(j) {
+					continue
+				}
+				e := col.Index(j).Interface().(Enum)
+				f.Enum[e.Text] = e.Value
+			}
+		}
+		if f.Type == fu.TensorType {
+			for j := 0; j < col.Len(); j++ {
+				if t.raw.Na[i].Bit(j) {
+					continue
+				}
+				c, h, w := col.Index(j).Interface().(fu.Tensor).Dimension()
+				if f.Dims == nil {
+					f.Dims = []int{c, h, w}
+				} else if f.Dims[0] != c || f.Dims[1] != h || f.Dims[2] != w {
+					f.Dims = nil
+					break
+				}
+			}
+		}
+		s.Fields[i] = f
+	}
+	return s
+}
+
+/*
+SchemaOf returns schema of row, so column is nullable only if the row has NA value in it
+*/
+func SchemaOf(lr fu.Struct) Schema {
+	s := Schema{Fields: make([]Field, len(lr.Names))}
+	for i, n := range lr.Names {
+		f := Field{Name: n, Nullable: lr.Na.Bit(i)}
+		if lr.Columns[i].IsValid() {
+			f.Type = lr.Columns[i].Type()
+			if x, ok := lr.Columns[i].Interface().(fu.Tensor); ok && !f.Nullable {
+				c, h, w := x.Dimension()
+				f.Dims = []int{c, h, w}
+			}
+		}
+		s.Fields[i] = f
+	}
+	return s
+}
+
+/*
+Schema returns schema of the first row of stream like SchemaOf does
+*/
+func (zf Lazy) Schema() (s Schema, err error) {
+	err = zf.First(1).Drain(func(v reflect.Value) error {
+		if v.Kind() != reflect.Bool {
+			s = SchemaOf(v.Interface().(fu.Struct))
+		}
+		return nil
+	})
+	return
+}
+
+// Names returns names of schema columns
+func (s Schema) Names() []string {
+	r := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		r[i] = f.Name
+	}
+	return r
+}
+
+// Field returns field by name
+func (s Schema) Field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func (s Schema) String() string {
+	r := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		r[i] = f.String()
+	}
+	return "{" + strings.Join(r, ", ") + "}"
+}
+
+func (f Field) String() string {
+	s := fmt.Sprintf("%v %v", f.Name, f.Type)
+	if f.Dims != nil {
+		s += fmt.Sprintf("%v", f.Dims)
+	}
+	if f.Enum != nil {
+		s += fmt.Sprintf(" enum(%d)", len(f.Enum))
+	}
+	if f.Nullable {
+		s += " null"
+	}
+	return s
+}
+
+type jsonField struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable,omitempty"`
+	Enum     Enumset `json:"enum,omitempty"`
+	Dims     []int   `json:"dims,omitempty"`
+}
+
+func (f Field) MarshalJSON() ([]byte, error) {
+	if f.Type == nil {
+		return nil, zorros.Errorf("column %v does not have type", f.Name)
+	}
+	if _, ok := schemaTypes[f.Type.String()]; !ok {
+		return nil, zorros.Errorf("column %v has type %v which can't be stored in schema", f.Name, f.Type)
+	}
+	return json.Marshal(jsonField{f.Name, f.Type.String(), f.Nullable, f.Enum, f.Dims})
+}
+
+func (f *Field) UnmarshalJSON(b []byte) (err error) {
+	x := jsonField{}
+	if err = json.Unmarshal(b, &x); err != nil {
+		return
+	}
+	t, ok := schemaTypes[x.Type]
+	if !ok {
+		return zorros.Errorf("column %v has unknown type %v", x.Name, x.Type)
+	}
+	*f = Field{x.Name, t, x.Nullable, x.Enum, x.Dims}
+	return
+}
+
+/*
+check returns error if value does not conform the field
+*/
+func (f Field) check(v reflect.Value, na bool) error {
+	if na {
+		if !f.Nullable {
+			return zorros.Errorf("column %v can't be NA", f.Name)
+		}
+		return nil
+	}
+	if !v.IsValid() {
+		return zorros.Errorf("column %v does not have value", f.Name)
+	}
+	if v.Type() != f.Type {
+		return zorros.Errorf("column %v has type %v but %v expected", f.Name, v.Type(), f.Type)
+	}
+	if f.Enum != nil {
+		ok := false
+		switch x := v.Interface().(type) {
+		case Enum:
+			_, ok = f.Enum[x.Text]
+		case string:
+			_, ok = f.Enum[x]
+		default:
+			if isInt(f.Type) {
+				for _, e := range f.Enum {
+					if ok = int64(e) == v.Int(); ok {
+						break
+					}
+				}
+			} else {
+				ok = true
+			}
+		}
+		if !ok {
+			return zorros.Errorf("column %v has value %v which is not in enumeration", f.Name, v.Interface())
+		}
+	}
+	if f.Dims != nil {
+		c, h, w := v.Interface().(fu.Tensor).Dimension()
+		if len(f.Dims) != 3 || f.Dims[0] != c || f.Dims[1] != h || f.Dims[2] != w {
+			return zorros.Errorf("column %v has tensor %dx%dx%d but %v expected", f.Name, c, h, w, f.Dims)
+		}
+	}
+	return nil
+}
+
+/*
+Validate checks that rows conform schema, bad rows are reported as RowError,
+so they can be skipped with OnError policy
+
+	err := source.Validate(schema).
+		OnError(tables.ErrorPolicy{DeadLetter: csv.Sink(iokit.File("bad.csv"))}).
+		Drain(sink)
+
+Row has to have all schema columns and only them, in any order.
+*/
+func (zf Lazy) Validate(schema Schema) Lazy {
+	return func() lazy.Stream {
+		z := zf()
+		return func(index uint64) (v reflect.Value, err error) {
+			if v, err = z(index); err != nil || index == lazy.STOP || v.Kind() == reflect.Bool {
+				return
+			}
+			lr := v.Interface().(fu.Struct)
+			if err = schema.validate(lr); err != nil {
+				return fu.False, &RowError{Row: lr, Err: zorros.Wrapf(err, "row %d: %s", index, err.Error())}
+			}
+			return
+		}
+	}
+}
+
+func (s Schema) validate(lr fu.Struct) error {
+	for _, f := range s.Fields {
+		j := lr.Pos(f.Name)
+		if j < 0 {
+			return zorros.Errorf("column %v does not exist", f.Name)
+		}
+		if err := f.check(lr.Columns[j], lr.Na.Bit(j)); err != nil {
+			return err
+		}
+	}
+	if len(lr.Names) != len(s.Fields) {
+		for _, n := range lr.Names {
+			if _, ok := s.Field(n); !ok {
+				return zorros.Errorf("column %v is not in schema", n)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Cast converts table to schema, columns go in schema order and columns which are not in schema are dropped.
+Missing nullable columns are filled with NA. Values are converted with fu.Convert,
+strings and integers are converted to Enum with enumset of the field.
+*/
+func (t *Table) Cast(schema Schema) (r *Table, err error) {
+	names := schema.Names()
+	columns := make([]reflect.Value, len(names))
+	na := make([]fu.Bits, len(names))
+	for i, f := range schema.Fields {
+		if _, ok := t.ColIfExists(f.Name); !ok {
+			if !f.Nullable {
+				return nil, zorros.Errorf("column %v does not exist", f.Name)
+			}
+			columns[i] = reflect.MakeSlice(reflect.SliceOf(f.Type), t.Len(), t.Len())
+			na[i] = fu.FillBits(t.Len())
+			continue
+		}
+		j := fu.IndexOf(f.Name, t.raw.Names)
+		if columns[i], err = f.cast(t.raw.Columns[j], t.raw.Na[j]); err != nil {
+			return
+		}
+		na[i] = t.raw.Na[j].Copy()
+		if !f.Nullable && na[i].Count() > 0 {
+			return nil, zorros.Errorf("column %v can't be NA", f.Name)
+		}
+	}
+	return MakeTable(names, columns, na, t.Len()), nil
+}
+
+func (f Field) cast(col reflect.Value, na fu.Bits) (r reflect.Value, err error) {
+	if f.Type == enumType && col.Type().Elem() != enumType {
+		if f.Enum == nil {
+			return r, zorros.Errorf("column %v can't be converted to Enum without enumset", f.Name)
+		}
+		texts := map[int]string{}
+		for k, v := range f.Enum {
+			texts[v] = k
+		}
+		r = reflect.MakeSlice(reflect.SliceOf(enumType), col.Len(), col.Len())
+		for i := 0; i < col.Len(); i++ {
+			if na.Bit(i) {
+				continue
+			}
+			e, ok := Enum{}, false
+			v := col.Index(i)
+			if v.Kind() == reflect.String {
+				e.Text = v.String()
+				e.Value, ok = f.Enum[e.Text]
+			} else if isInt(v.Type()) {
+				e.Value = int(v.Int())
+				e.Text, ok = texts[e.Value]
+			}
+			if !ok {
+				return r, zorros.Errorf("column %v has value %v which is not in enumeration", f.Name, v.Interface())
+			}
+			r.Index(i).Set(reflect.ValueOf(e))
+		}
+		return
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = zorros.Errorf("column %v can't be converted to %v: %v", f.Name, f.Type, e)
+		}
+	}()
+	r = fu.ConvertSlice(col, na, f.Type)
+	for i := 0; i < r.Len() && f.Enum != nil && f.Type != enumType; i++ {
+		if err = f.check(r.Index(i), na.Bit(i)); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,329 @@
+package tables
+
+import (
+	"bufio"
+	"container/heap"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Desc option makes sorting descending
+type Desc bool
+
+// DESC is the option of descending sort order
+const DESC = Desc(true)
+
+// RunSize is the count of rows sorted in memory before spilling them to temporary file
+type RunSize int
+
+// DefaultRunSize is the count of rows sorted in memory by default
+const DefaultRunSize = 100000
+
+/*
+SortBy sorts stream by specified columns
+
+	err := csv.Source(iokit.File("huge.csv"), csv.Int("Id"), csv.Time("Date")).
+		SortBy([]string{"Date", "Id"}, tables.DESC, tables.RunSize(1000000)).
+		Drain(csv.Sink(iokit.File("sorted.csv")))
+
+Stream is read to the end on the first row requested, rows are sorted in memory by runs of RunSize rows,
+sorted runs are spilled to temporary files and merged back into the sorted stream.
+Sort is stable and NA values go after all other values for both orders.
+Columns can be string or []string, sorted columns have to be numeric, string, bool or time.Time.
+Columns of stream having more than RunSize rows have to be numeric, string, bool, time.Time or implement encoding.BinaryMarshaler.
+*/
+func (zf Lazy) SortBy(cols interface{}, opts ...interface{}) Lazy {
+	names := sortColumns(cols)
+	desc := fu.BoolOption(Desc(false), opts)
+	runSize := fu.Maxi(1, fu.IntOption(RunSize(DefaultRunSize), opts))
+	return func() lazy.Stream {
+		z := zf()
+		s := &sorter{names: names, desc: desc, runSize: runSize}
+		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
+		started := false
+		return func(index uint64) (v reflect.Value, err error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				if f.Set() {
+					s.close()
+				}
+				return z(index)
+			}
+			if !wc.Wait(index) {
+				return reflect.ValueOf(false), nil
+			}
+			if !started {
+				started = true
+				err = s.read(z)
+			}
+			lr, ok := fu.Struct{}, false
+			if err == nil {
+				lr, ok, err = s.next()
+			}
+			if err != nil || !ok {
+				wc.Stop()
+				return reflect.ValueOf(false), err
+			}
+			wc.Inc()
+			return reflect.ValueOf(lr), nil
+		}
+	}
+}
+
+func sortColumns(cols interface{}) []string {
+	switch x := cols.(type) {
+	case string:
+		return []string{x}
+	case []string:
+		return x
+	}
+	panic(zorros.Panic(zorros.Errorf("sort columns have to be string or []string, but %v", reflect.TypeOf(cols))))
+}
+
+type sorter struct {
+	names   []string
+	desc    bool
+	runSize int
+	keys    []int
+	rowCodec
+	buf   []fu.Struct
+	files []iokit.TemporaryFile
+	merge *runMerge
+}
+
+func (s *sorter) read(z lazy.Stream) (err error) {
+	for i := uint64(0); ; i++ {
+		var v reflect.Value
+		if v, err = z(i); err != nil {
+			return
+		}
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				continue
+			}
+			break
+		}
+		lr := v.Interface().(fu.Struct)
+		if s.keys == nil {
+			if err = s.init(lr); err != nil {
+				return
+			}
+		}
+		// run is spilled only when there is another row, so stream fitting into one run is not written to disk
+		if len(s.buf) >= s.runSize {
+			if err = s.spill(); err != nil {
+				return
+			}
+		}
+		s.buf = append(s.buf, lr)
+	}
+	s.sortRun(s.buf)
+	runs := make([]*runCursor, 0, len(s.files)+1)
+	for i, f := range s.files {
+		if err = f.Reset(); err != nil {
+			return zorros.Trace(err)
+		}
+		runs = append(runs, &runCursor{run: i, rd: bufio.NewReader(f)})
+	}
+	runs = append(runs, &runCursor{run: len(s.files), rows: s.buf})
+	s.merge = &runMerge{sorter: s}
+	for _, c := range runs {
+		if err = s.merge.push(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *sorter) init(lr fu.Struct) error {
+	s.keys = make([]int, len(s.names))
+	for i, n := range s.names {
+		if s.keys[i] = lr.Pos(n); s.keys[i] < 0 {
+			return zorros.Errorf("sort column %v does not exist", n)
+		}
+		if !sortable(lr.Columns[s.keys[i]]) {
+			return zorros.Errorf("sort column %v of type %v is not sortable", n, lr.Columns[s.keys[i]].Type())
+		}
+	}
+	c, err := newRowCodec(lr)
+	if err != nil {
+		return err
+	}
+	s.rowCodec = *c
+	return nil
+}
+
+func (s *sorter) sortRun(rows []fu.Struct) {
+	sort.SliceStable(rows, func(i, j int) bool { return s.less(rows[i], rows[j]) })
+}
+
+func (s *sorter) spill() (err error) {
+	s.sortRun(s.buf)
+	var f iokit.TemporaryFile
+	if f, err = iokit.Tempfile("sort-run-*"); err != nil {
+		return zorros.Trace(err)
+	}
+	s.files = append(s.files, f)
+	wr := bufio.NewWriter(f)
+	for _, lr := range s.buf {
+		if err = s.encode(wr, lr); err != nil {
+			return
+		}
+	}
+	if err = wr.Flush(); err != nil {
+		return zorros.Trace(err)
+	}
+	s.buf = s.buf[:0]
+	return
+}
+
+func (s *sorter) next() (lr fu.Struct, ok bool, err error) {
+	if s.merge.Len() == 0 {
+		return
+	}
+	c := heap.Pop(s.merge).(*runCursor)
+	lr = c.row
+	if err = s.merge.push(c); err != nil {
+		return
+	}
+	return lr, true, nil
+}
+
+func (s *sorter) close() {
+	for _, f := range s.files {
+		f.Close()
+	}
+	s.files = nil
+}
+
+func (s *sorter) less(a, b fu.Struct) bool {
+	return s.compare(a, b) < 0
+}
+
+/*
+compare compares rows by sort columns, NA values go after all other values
+*/
+func (s *sorter) compare(a, b fu.Struct) int {
+	for _, k := range s.keys {
+		an, bn := a.Na.Bit(k), b.Na.Bit(k)
+		if an || bn {
+			if an && bn {
+				continue
+			}
+			if an {
+				return 1
+			}
+			return -1
+		}
+		c := compareValues(a.Columns[k], b.Columns[k])
+		if s.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func sortable(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	}
+	return v.Type() == fu.Ts
+}
+
+func compareValues(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp(a.Float() < b.Float(), a.Float() > b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	}
+	x, y := a.Interface().(time.Time), b.Interface().(time.Time)
+	return cmp(x.Before(y), x.After(y))
+}
+
+func cmp(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+/*
+runCursor is the current row of sorted run, it's read from temporary file or from in-memory rows
+*/
+type runCursor struct {
+	run  int
+	row  fu.Struct
+	rd   *bufio.Reader
+	rows []fu.Struct
+}
+
+/*
+runMerge is the heap of runs ordered by current rows and then by runs to keep sort stable
+*/
+type runMerge struct {
+	sorter  *sorter
+	cursors []*runCursor
+}
+
+func (m *runMerge) Len() int { return len(m.cursors) }
+func (m *runMerge) Less(i, j int) bool {
+	a, b := m.cursors[i], m.cursors[j]
+	if c := m.sorter.compare(a.row, b.row); c != 0 {
+		return c < 0
+	}
+	return a.run < b.run
+}
+func (m *runMerge) Swap(i, j int)      { m.cursors[i], m.cursors[j] = m.cursors[j], m.cursors[i] }
+func (m *runMerge) Push(x interface{}) { m.cursors = append(m.cursors, x.(*runCursor)) }
+func (m *runMerge) Pop() interface{} {
+	c := m.cursors[len(m.cursors)-1]
+	m.cursors = m.cursors[:len(m.cursors)-1]
+	return c
+}
+
+/*
+push reads the next row of run and pushes run back into heap if there is the row
+*/
+func (m *runMerge) push(c *runCursor) (err error) {
+	if c.rd != nil {
+		if c.row, err = m.sorter.decode(c.rd); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+	} else {
+		if len(c.rows) == 0 {
+			return
+		}
+		c.row, c.rows = c.rows[0], c.rows[1:]
+	}
+	heap.Push(m, c)
+	return
+}
@@ -0,0 +1,196 @@
+package tables
+
+import (
+	"go4ml.xyz/base/fu"
+	"golang.org/x/xerrors"
+	"reflect"
+)
+
+/*
+SparseMatrix the presentation of features in compressed sparse row (CSR) format,
+features of the row i are Values[Indptr[i]:Indptr[i+1]] in columns Indices[Indptr[i]:Indptr[i+1]]
+*/
+type SparseMatrix struct {
+	Indptr        []int // Length+1 offsets of rows
+	Indices       []int
+	Values        []float32
+	Labels        []float32
+	Width, Length int
+	LabelsWidth   int // 0 means no labels defined
+}
+
+/*
+SparseMatrix returns sparse matrix of features with labels if label is not empty,
+features can be numeric, bool, enum, fu.Tensor or fu.SparseTensor columns.
+Enum column is one-hot encoded by Enum.Value, so its width is the maximal value + 1.
+NA values of scalar columns are not stored as well as zeros.
+*/
+func (t *Table) SparseMatrix(features []string, label string) (m SparseMatrix, err error) {
+	length := t.Len()
+	m.Length = length
+	m.Indptr = make([]int, length+1)
+	type part struct {
+		offset, width int
+		row           func(int, func(int, float32))
+	}
+	parts := make([]part, len(features))
+	for i, n := range features {
+		var p part
+		if p.width, p.row, err = sparseColumn(t.Col(n)); err != nil {
+			return m, xerrors.Errorf("column %v: %w", n, err)
+		}
+		p.offset = m.Width
+		m.Width += p.width
+		parts[i] = p
+	}
+	for j := 0; j < length; j++ {
+		for _, p := range parts {
+			off := p.offset
+			p.row(j, func(k int, v float32) {
+				if v != 0 {
+					m.Indices = append(m.Indices, off+k)
+					m.Values = append(m.Values, v)
+				}
+			})
+		}
+		m.Indptr[j+1] = len(m.Indices)
+	}
+	if label != "" {
+		var lm Matrix
+		if _, lm, err = t.MatrixWithLabelIf(nil, label, "", nil); err != nil {
+			return
+		}
+		m.Labels, m.LabelsWidth = lm.Labels, lm.LabelsWidth
+	}
+	return
+}
+
+func sparseColumn(c *Column) (width int, row func(int, func(int, float32)), err error) {
+	switch c.Type() {
+	case enumType:
+		x := c.Inspect().([]Enum)
+		for j, e := range x {
+			if !c.Na(j) {
+				width = fu.Maxi(width, e.Value+1)
+			}
+		}
+		return width, func(j int, f func(int, float32)) {
+			if !c.Na(j) {
+				f(x[j].Value, 1)
+			}
+		}, nil
+	case fu.SparseTensorType:
+		x := c.Inspect().([]fu.SparseTensor)
+		return sparseWidth(x), func(j int, f func(int, float32)) {
+			for i, k := range x[j].Indices {
+				f(k, x[j].Values[i])
+			}
+		}, nil
+	case fu.TensorType:
+		x := c.Inspect().([]fu.Tensor)
+		if len(x) > 0 {
+			width = x[0].Volume()
+		}
+		for _, t := range x {
+			if t.Volume() != width {
+				return 0, nil, xerrors.Errorf("tensors with different volumes found in one column")
+			}
+		}
+		return width, func(j int, f func(int, float32)) {
+			for k, v := range x[j].Floats32() {
+				f(k, v)
+			}
+		}, nil
+	}
+	switch c.Type().Kind() {
+	case reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		if c.Type() != fu.Fixed8Type {
+			return 0, nil, xerrors.Errorf("unsupported column type %v", c.Type())
+		}
+	}
+	x := c.ExtractAs(fu.Float32, true).([]float32)
+	return 1, func(j int, f func(int, float32)) {
+		if !c.Na(j) {
+			f(0, x[j])
+		}
+	}, nil
+}
+
+/*
+sparseWidth returns the maximal dense length of sparse tensors
+*/
+func sparseWidth(x []fu.SparseTensor) (width int) {
+	for _, s := range x {
+		width = fu.Maxi(width, s.Len())
+	}
+	return
+}
+
+/*
+Row returns features of the row as sparse tensor
+*/
+func (m SparseMatrix) Row(i int) fu.SparseTensor {
+	a, b := m.Indptr[i], m.Indptr[i+1]
+	return fu.MakeSparseTensor(m.Width, m.Indices[a:b], m.Values[a:b])
+}
+
+/*
+Slice returns rows [from,to) of the matrix, features and labels are shared with the original matrix
+*/
+func (m SparseMatrix) Slice(from, to int) SparseMatrix {
+	a := m.Indptr[from]
+	indptr := make([]int, to-from+1)
+	for i := range indptr {
+		indptr[i] = m.Indptr[from+i] - a
+	}
+	b := m.Indptr[to]
+	r := SparseMatrix{indptr, m.Indices[a:b], m.Values[a:b], nil, m.Width, to - from, m.LabelsWidth}
+	if m.LabelsWidth > 0 {
+		r.Labels = m.Labels[from*m.LabelsWidth : to*m.LabelsWidth]
+	}
+	return r
+}
+
+/*
+Dense converts sparse matrix to the dense one
+*/
+func (m SparseMatrix) Dense() Matrix {
+	features := make([]float32, m.Width*m.Length)
+	for i := 0; i < m.Length; i++ {
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			features[i*m.Width+m.Indices[k]] = m.Values[k]
+		}
+	}
+	return Matrix{features, m.Labels, m.Width, m.Length, m.LabelsWidth}
+}
+
+/*
+Sparse converts dense matrix to the sparse one skipping zeros
+*/
+func (m Matrix) Sparse() SparseMatrix {
+	r := SparseMatrix{Indptr: make([]int, m.Length+1), Labels: m.Labels, Width: m.Width, Length: m.Length, LabelsWidth: m.LabelsWidth}
+	for i := 0; i < m.Length; i++ {
+		for k, v := range m.Features[i*m.Width : (i+1)*m.Width] {
+			if v != 0 {
+				r.Indices = append(r.Indices, k)
+				r.Values = append(r.Values, v)
+			}
+		}
+		r.Indptr[i+1] = len(r.Indices)
+	}
+	return r
+}
+
+/*
+AsColumn converts sparse features into Column of fu.SparseTensor
+*/
+func (m SparseMatrix) AsColumn() *Column {
+	column := make([]fu.SparseTensor, m.Length)
+	for i := range column {
+		column[i] = m.Row(i)
+	}
+	return &Column{column: reflect.ValueOf(column)}
+}
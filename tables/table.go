@@ -222,36 +222,40 @@ func New(o interface{}) *Table {
 			tp = tp.Elem()
 		}
 
-		nl := tp.NumField()
-		names := make([]string, 0, nl)
-		columns := make([]reflect.Value, 0, nl)
-		for i := 0; i < nl; i++ {
-			fv := tp.Field(i)
-			names = append(names, fv.Name)
-			col := reflect.MakeSlice(reflect.SliceOf(fv.Type), l, l)
-			columns = append(columns, col)
+		fields := fu.BoundFields(tp)
+		names := make([]string, len(fields))
+		columns := make([]reflect.Value, len(fields))
+		na := make([]fu.Bits, len(fields))
+		for i, f := range fields {
+			names[i] = f.Name
+			col := reflect.MakeSlice(reflect.SliceOf(fu.FieldType(f.Type)), l, l)
+			columns[i] = col
 			for j := 0; j < l; j++ {
 				x := q.Index(j)
 				if x.Kind() == reflect.Ptr {
 					x = x.Elem()
 				}
-				col.Index(j).Set(x.Field(i))
+				v, isna := fu.FieldValue(x.FieldByIndex(f.Index))
+				col.Index(j).Set(v)
+				if isna {
+					na[i].Set(j, true)
+				}
 			}
 		}
 
-		return MakeTable(names, columns, make([]fu.Bits, len(names)), l)
+		return MakeTable(names, columns, na, l)
 
 	case reflect.Chan: // New(chan struct{})
 		tp := q.Type().Elem()
-		nl := tp.NumField()
-		names := make([]string, nl)
-		columns := make([]reflect.Value, nl)
+		fields := fu.BoundFields(tp)
+		names := make([]string, len(fields))
+		columns := make([]reflect.Value, len(fields))
+		na := make([]fu.Bits, len(fields))
 		scase := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: q}}
 
-		for i := 0; i < nl; i++ {
-			fv := tp.Field(i)
-			names[i] = fv.Name
-			columns[i] = reflect.MakeSlice(reflect.SliceOf(fv.Type), 0, 1)
+		for i, f := range fields {
+			names[i] = f.Name
+			columns[i] = reflect.MakeSlice(reflect.SliceOf(fu.FieldType(f.Type)), 0, 1)
 		}
 
 		length := 0
@@ -260,13 +264,17 @@ func New(o interface{}) *Table {
 			if !ok {
 				break
 			}
-			for i := 0; i < nl; i++ {
-				columns[i] = reflect.Append(columns[i], v.Field(i))
+			for i, f := range fields {
+				x, isna := fu.FieldValue(v.FieldByIndex(f.Index))
+				columns[i] = reflect.Append(columns[i], x)
+				if isna {
+					na[i].Set(length, true)
+				}
 			}
 			length++
 		}
 
-		return MakeTable(names, columns, make([]fu.Bits, len(names)), length)
+		return MakeTable(names, columns, na, length)
 
 	case reflect.Map: // New(map[string]interface{}{})
 		m := o.(map[string]interface{})
@@ -512,9 +520,11 @@ Sort sorts rows by specified Columns and returns new sorted table
 	t.Row(0) -> {Name: "Ivanov", "Age": 32, "Rate", 1.2}
 	q := t.Sort("Name",tables.DESC)
 	q.Row(0) -> {Name: "Petrov", "Age": 44, "Rate", 1.5}
+	q = t.Sort([]string{"Age","Name"})
 */
-func (t *Table) Sort(opt interface{}) *Table {
-	return nil
+func (t *Table) Sort(cols interface{}, opts ...interface{}) *Table {
+	// table is already in memory, so it is sorted by one run and never spilled to disk
+	return t.Lazy().SortBy(cols, append(opts, RunSize(fu.Maxi(1, t.Len())))...).LuckyCollect()
 }
 
 /*
@@ -580,11 +590,8 @@ func (t *Table) FillNa(r interface{}) *Table {
 
 	if v.Kind() == reflect.Struct {
 		m = map[string]interface{}{}
-		n := v.NumField()
-		ft := v.Type()
-		for i := 0; i < n; i++ {
-			f := ft.Field(i)
-			m[f.Name] = v.Field(i).Interface()
+		for _, f := range fu.BoundFields(v.Type()) {
+			m[f.Name] = v.FieldByIndex(f.Index).Interface()
 		}
 	} else {
 		m = r.(map[string]interface{})
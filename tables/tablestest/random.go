@@ -0,0 +1,350 @@
+package tablestest
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/zorros"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Rows is the count of generated rows
+type Rows int
+
+// NaRate is the probability of NA value in nullable column
+type NaRate float64
+
+// Cardinality is the count of distinct values of generated string and Enum columns
+type Cardinality int
+
+// Seed is the seed of generated values, the same seed gives the same table
+type Seed int
+
+// Tries is the count of tables checked by Check
+type Tries int
+
+// DefaultRows is the count of generated rows by default
+const DefaultRows = 100
+
+// DefaultNaRate is the probability of NA value by default
+const DefaultNaRate = 0.1
+
+// DefaultCardinality is the count of distinct string and Enum values by default
+const DefaultCardinality = 10
+
+// DefaultTries is the count of tables checked by Check by default
+const DefaultTries = 100
+
+var enumType = reflect.TypeOf(tables.Enum{})
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Types are column types supported by generator
+var Types = []reflect.Type{
+	fu.Bool, fu.Int, fu.Int8, fu.Int16, fu.Int32, fu.Int64,
+	fu.Uint, fu.Uint8, fu.Uint16, fu.Uint32, fu.Uint64,
+	fu.Float32, fu.Float64, fu.String, fu.Ts, fu.TensorType, fu.Fixed8Type, enumType,
+}
+
+/*
+generator produces rows of random table, every row depends only on seed and row index,
+so rows of lazy stream can be generated concurrently
+*/
+type generator struct {
+	fields []tables.Field
+	names  []string
+	enums  [][]tables.Enum
+	rows   int
+	naRate float64
+	card   int
+	seed   uint32
+}
+
+func newGenerator(schema tables.Schema, opts []interface{}) *generator {
+	g := &generator{
+		fields: schema.Fields,
+		names:  schema.Names(),
+		enums:  make([][]tables.Enum, len(schema.Fields)),
+		rows:   fu.IntOption(Rows(DefaultRows), opts),
+		naRate: fu.Option(NaRate(DefaultNaRate), opts).Float(),
+		card:   fu.Maxi(1, fu.IntOption(Cardinality(DefaultCardinality), opts)),
+		seed:   uint32(fu.IntOption(Seed(0), opts)),
+	}
+	for i, f := range g.fields {
+		if !supported(f.Type) {
+			panic(zorros.Panic(zorros.Errorf("column %v has type %v which can't be generated", f.Name, f.Type)))
+		}
+		if f.Type == enumType {
+			if f.Enum != nil {
+				for k, v := range f.Enum {
+					g.enums[i] = append(g.enums[i], tables.Enum{Text: k, Value: v})
+				}
+				// map iteration order is random
+				e := g.enums[i]
+				sort.Slice(e, func(a, b int) bool { return e[a].Value < e[b].Value })
+			} else {
+				for k := 0; k < g.card; k++ {
+					g.enums[i] = append(g.enums[i], tables.Enum{Text: fmt.Sprintf("e%d", k), Value: k})
+				}
+			}
+		}
+	}
+	return g
+}
+
+func supported(t reflect.Type) bool {
+	for _, x := range Types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) row(index int) fu.Struct {
+	nr := fu.NaiveRandom{Value: g.seed ^ uint32(index)*2654435761}
+	nr.Uint32()
+	lr := fu.Struct{Names: g.names, Columns: make([]reflect.Value, len(g.fields))}
+	for i, f := range g.fields {
+		if f.Nullable && nr.Float() < g.naRate {
+			lr.Columns[i] = fu.Nan(f.Type)
+			lr.Na.Set(i, true)
+		} else {
+			lr.Columns[i] = g.value(&nr, i)
+		}
+	}
+	return lr
+}
+
+func (g *generator) value(nr *fu.NaiveRandom, i int) reflect.Value {
+	f := g.fields[i]
+	switch f.Type {
+	case fu.Bool:
+		return reflect.ValueOf(nr.Uint32()&1 != 0)
+	case fu.Int, fu.Int64:
+		return reflect.ValueOf(int64(nr.Uint32())<<32 | int64(nr.Uint32())).Convert(f.Type)
+	case fu.Uint, fu.Uint64:
+		return reflect.ValueOf(uint64(nr.Uint32())<<32 | uint64(nr.Uint32())).Convert(f.Type)
+	case fu.Int8, fu.Int16, fu.Int32:
+		return reflect.ValueOf(int32(nr.Uint32())).Convert(f.Type)
+	case fu.Uint8, fu.Uint16, fu.Uint32:
+		return reflect.ValueOf(nr.Uint32()).Convert(f.Type)
+	case fu.Float32, fu.Float64:
+		return reflect.ValueOf((nr.Float()*2 - 1) * 1000).Convert(f.Type)
+	case fu.String:
+		return reflect.ValueOf(fmt.Sprintf("s%d", nr.Int()%g.card))
+	case fu.Ts:
+		return reflect.ValueOf(epoch.Add(time.Duration(nr.Uint32()%(366*24*3600)) * time.Second))
+	case fu.Fixed8Type:
+		return reflect.ValueOf(fu.RawAsFixed8(int8(nr.Int()%201 - 100)))
+	case enumType:
+		return reflect.ValueOf(g.enums[i][nr.Int()%len(g.enums[i])])
+	}
+	// fu.TensorType
+	c, h, w := 1, 1, 1+nr.Int()%8
+	if len(f.Dims) == 3 {
+		c, h, w = f.Dims[0], f.Dims[1], f.Dims[2]
+	}
+	v := make([]float32, c*h*w)
+	for j := range v {
+		v[j] = float32(nr.Float()*2 - 1)
+	}
+	return reflect.ValueOf(fu.MakeFloat32Tensor(c, h, w, v))
+}
+
+/*
+Random generates table with columns described by schema
+
+	schema := tables.Schema{Fields: []tables.Field{
+		{Name: "Id", Type: fu.Int},
+		{Name: "Rate", Type: fu.Float32, Nullable: true},
+		{Name: "Kind", Type: reflect.TypeOf(tables.Enum{}), Nullable: true},
+		{Name: "Vector", Type: fu.TensorType, Dims: []int{1, 1, 4}}}}
+	q := tablestest.Random(schema, tablestest.Rows(1000), tablestest.NaRate(0.3), tablestest.Seed(42))
+
+Only nullable columns have NA values. Enum columns take values from field enumset,
+or from Cardinality values e0, e1, ... if enumset is not specified.
+String columns have Cardinality distinct values s0, s1, ...
+Tensors have field dims or random dims 1x1xN if dims are not specified.
+*/
+func Random(schema tables.Schema, opts ...interface{}) *tables.Table {
+	g := newGenerator(schema, opts)
+	columns := make([]reflect.Value, len(g.fields))
+	na := make([]fu.Bits, len(g.fields))
+	for i, f := range g.fields {
+		columns[i] = reflect.MakeSlice(reflect.SliceOf(f.Type), g.rows, g.rows)
+	}
+	for j := 0; j < g.rows; j++ {
+		lr := g.row(j)
+		for i, v := range lr.Columns {
+			columns[i].Index(j).Set(v)
+			na[i].Set(j, lr.Na.Bit(i))
+		}
+	}
+	return tables.MakeTable(g.names, columns, na, g.rows)
+}
+
+/*
+RandomLazy generates stream of the same rows as Random generates for the same options
+*/
+func RandomLazy(schema tables.Schema, opts ...interface{}) tables.Lazy {
+	g := newGenerator(schema, opts)
+	return func() lazy.Stream {
+		flag := &fu.AtomicFlag{Value: 1}
+		return func(index uint64) (v reflect.Value, err error) {
+			if index == lazy.STOP {
+				flag.Clear()
+			} else if flag.State() && index < uint64(g.rows) {
+				return reflect.ValueOf(g.row(int(index))), nil
+			}
+			return reflect.ValueOf(false), nil
+		}
+	}
+}
+
+/*
+RandomSchema generates schema of width columns named C0, C1, ... having random types from Types,
+random columns are nullable and tensor columns have random dims
+*/
+func RandomSchema(width int, seed int) tables.Schema {
+	nr := fu.NaiveRandom{Value: uint32(seed)}
+	nr.Uint32()
+	s := tables.Schema{Fields: make([]tables.Field, width)}
+	for i := range s.Fields {
+		f := tables.Field{Name: fmt.Sprintf("C%d", i), Type: Types[nr.Int()%len(Types)], Nullable: nr.Uint32()&1 != 0}
+		if f.Type == fu.TensorType && nr.Uint32()&1 != 0 {
+			f.Dims = []int{1, 1 + nr.Int()%3, 1 + nr.Int()%3}
+		}
+		s.Fields[i] = f
+	}
+	return s
+}
+
+/*
+Check generates random tables and fails test if property returns error for any of them,
+the failed table is shrunk to the minimal one still failing and reported with seed reproducing it
+
+	tablestest.Check(t, schema, func(q *tables.Table) error {
+		if r := q.DropNa(); r.Len() > q.Len() {
+			return zorros.Errorf("DropNa adds rows")
+		}
+		return nil
+	}, tablestest.Tries(50), tablestest.NaRate(0.5))
+
+Tables have random count of rows from 0 to Rows, seeds of tables are Seed, Seed+1, ...
+*/
+func Check(t T, schema tables.Schema, property func(*tables.Table) error, opts ...interface{}) {
+	t.Helper()
+	tries := fu.IntOption(Tries(DefaultTries), opts)
+	rows := fu.IntOption(Rows(DefaultRows), opts)
+	seed := fu.IntOption(Seed(0), opts)
+	for i := 0; i < tries; i++ {
+		s := seed + i
+		nr := fu.NaiveRandom{Value: uint32(s)}
+		n := int(nr.Uint32() % uint32(rows+1))
+		q := Random(schema, append([]interface{}{Seed(s), Rows(n)}, opts...)...)
+		if panicked, err := failure(property, q); err != nil {
+			// shrunk table has to fail the same way, so removed column does not make property panic instead of failing
+			r := Shrink(q, func(x *tables.Table) bool {
+				p, e := failure(property, x)
+				return e != nil && p == panicked
+			})
+			_, err = failure(property, r)
+			t.Fatalf("property failed on table generated with seed %d and %d rows: %v\nshrunk table:\n%v", s, n, err, r)
+			return
+		}
+	}
+}
+
+/*
+failure calls property and returns its error or recovered panic
+*/
+func failure(property func(*tables.Table) error, q *tables.Table) (panicked bool, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			panicked, err = true, zorros.Errorf("panic: %v", e)
+		}
+	}()
+	return false, property(q)
+}
+
+/*
+Shrink returns the minimal table derived from failed one which still fails
+
+	r := tablestest.Shrink(q, func(x *tables.Table) bool { return !tables.Equal(x, x.Concat(x.Slice(0, 0))) })
+
+It removes blocks of rows and then single rows, removes columns,
+and replaces numeric, bool and string values by zero values while table still fails.
+*/
+func Shrink(q *tables.Table, fails func(*tables.Table) bool) *tables.Table {
+	rows := make([]int, q.Len())
+	for i := range rows {
+		rows[i] = i
+	}
+	for n := len(rows); n > 0; n /= 2 {
+		for i := 0; i+n <= len(rows); {
+			r := append(append([]int{}, rows[:i]...), rows[i+n:]...)
+			if fails(pick(q, r)) {
+				rows = r
+			} else {
+				i += n
+			}
+		}
+	}
+	q = pick(q, rows)
+	for _, n := range q.Names() {
+		if x := q.Except(n); fails(x) {
+			q = x
+		}
+	}
+	raw := q.Raw()
+	for i, c := range raw.Columns {
+		z := reflect.Zero(c.Type().Elem())
+		if textual(z.Type()) != z.Type() {
+			continue
+		}
+		for j := 0; j < raw.Length; j++ {
+			if raw.Na[i].Bit(j) || reflect.DeepEqual(c.Index(j).Interface(), z.Interface()) {
+				continue
+			}
+			x := simplify(q, i, j, z)
+			if fails(x) {
+				q, raw = x, x.Raw()
+				c = raw.Columns[i]
+			}
+		}
+	}
+	return q
+}
+
+/*
+pick returns table with specified rows
+*/
+func pick(q *tables.Table, rows []int) *tables.Table {
+	raw := q.Raw()
+	columns := make([]reflect.Value, len(raw.Columns))
+	na := make([]fu.Bits, len(raw.Columns))
+	for i, c := range raw.Columns {
+		columns[i] = reflect.MakeSlice(c.Type(), len(rows), len(rows))
+		for k, j := range rows {
+			columns[i].Index(k).Set(c.Index(j))
+			na[i].Set(k, raw.Na[i].Bit(j))
+		}
+	}
+	return tables.MakeTable(raw.Names, columns, na, len(rows))
+}
+
+/*
+simplify returns copy of table having value of column i in row j replaced
+*/
+func simplify(q *tables.Table, i, j int, v reflect.Value) *tables.Table {
+	raw := q.Raw()
+	columns := append([]reflect.Value{}, raw.Columns...)
+	c := raw.Columns[i]
+	columns[i] = reflect.MakeSlice(c.Type(), c.Len(), c.Len())
+	reflect.Copy(columns[i], c)
+	columns[i].Index(j).Set(v)
+	return tables.MakeTable(raw.Names, columns, raw.Na, raw.Length)
+}
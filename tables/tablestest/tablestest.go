@@ -0,0 +1,212 @@
+/*
+Package tablestest provides assertions comparing tables and random tables generator for property tests
+
+	func Test_Pipeline(t *testing.T) {
+		q := pipeline(source).LuckyCollect()
+		tablestest.AssertEqual(t, q, expected, tables.Key("Id"))
+		tablestest.AssertGolden(t, q, "testdata/pipeline.csv", tables.Tolerance(1e-6))
+	}
+
+Golden files are written instead of comparison when environment variable GOLDEN_UPDATE is set to 1
+
+	tablestest.Check(t, tablestest.RandomSchema(8, 42), func(q *tables.Table) error {
+		if !tables.Equal(q.Concat(q.Slice(0, 0)), q) {
+			return zorros.Errorf("Concat with empty table changes table")
+		}
+		return nil
+	})
+*/
+package tablestest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/zorros"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UpdateEnv is the environment variable which makes AssertGolden write golden files
+const UpdateEnv = "GOLDEN_UPDATE"
+
+/*
+T is the subset of testing.TB used by assertions
+*/
+type T interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+/*
+AssertEqual fails test with report of difference if tables are not equal, options are the same as for tables.Diff
+*/
+func AssertEqual(t T, actual, expected *tables.Table, opts ...interface{}) {
+	t.Helper()
+	if d := tables.Diff(actual, expected, opts...); !d.Empty() {
+		t.Fatalf("tables are not equal:\n%v", d)
+	}
+}
+
+/*
+AssertGolden compares table with golden CSV file, options are the same as for tables.Diff
+
+Golden file is written if it does not exist or environment variable GOLDEN_UPDATE is set to 1.
+Values are written as fmt.Sprint does and NA values are empty cells.
+When golden file is read, columns are converted to types of table columns if they are numeric or bool,
+other columns are compared as strings, so empty string is equal to NA.
+*/
+func AssertGolden(t T, actual *tables.Table, path string, opts ...interface{}) {
+	t.Helper()
+	if _, err := os.Stat(path); os.Getenv(UpdateEnv) == "1" || os.IsNotExist(err) {
+		if err := WriteGolden(actual, path); err != nil {
+			t.Fatalf("failed to write golden file %v: %v", path, err)
+		}
+		return
+	}
+	expected, err := ReadGolden(path, actual)
+	if err != nil {
+		t.Fatalf("failed to read golden file %v: %v", path, err)
+	}
+	if d := tables.Diff(Textual(actual), expected, opts...); !d.Empty() {
+		t.Fatalf("table does not match golden file %v (set %v=1 to update it):\n%v", path, UpdateEnv, d)
+	}
+}
+
+/*
+WriteGolden writes table into golden CSV file creating directory if it does not exist
+*/
+func WriteGolden(q *tables.Table, path string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	wr := csv.NewWriter(f)
+	if err = wr.Write(q.Names()); err != nil {
+		return
+	}
+	for i := 0; i < q.Len(); i++ {
+		lr := q.Index(i)
+		r := make([]string, len(lr.Names))
+		for j, v := range lr.Columns {
+			if !lr.Na.Bit(j) {
+				r[j] = fmt.Sprint(v.Interface())
+			}
+		}
+		if err = wr.Write(r); err != nil {
+			return
+		}
+	}
+	wr.Flush()
+	if err = wr.Error(); err == nil {
+		err = f.Close()
+	}
+	return
+}
+
+/*
+ReadGolden reads golden CSV file converting columns to types of columns of like table
+*/
+func ReadGolden(path string, like *tables.Table) (*tables.Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, zorros.Errorf("golden file does not have header")
+	}
+	names, rows := rows[0], rows[1:]
+	columns := make([]reflect.Value, len(names))
+	na := make([]fu.Bits, len(names))
+	for j, n := range names {
+		tp := fu.String
+		if c, ok := like.ColIfExists(n); ok && textual(c.Type()) == c.Type() {
+			tp = c.Type()
+		}
+		col := reflect.MakeSlice(reflect.SliceOf(tp), len(rows), len(rows))
+		for i, r := range rows {
+			if r[j] == "" {
+				na[j].Set(i, true)
+				col.Index(i).Set(fu.Nan(tp))
+				continue
+			}
+			v, err := parse(r[j], tp)
+			if err != nil {
+				return nil, zorros.Wrapf(err, "column %v row %d: %s", n, i, err.Error())
+			}
+			col.Index(i).Set(v)
+		}
+		columns[j] = col
+	}
+	return tables.MakeTable(names, columns, na, len(rows)), nil
+}
+
+/*
+Textual converts columns which are not numeric or bool to strings like they are written into golden file
+*/
+func Textual(q *tables.Table) *tables.Table {
+	raw := q.Raw()
+	columns := make([]reflect.Value, len(raw.Names))
+	na := make([]fu.Bits, len(raw.Names))
+	for j, c := range raw.Columns {
+		na[j] = raw.Na[j].Copy()
+		if tp := c.Type().Elem(); textual(tp) == tp {
+			columns[j] = c
+			continue
+		}
+		col := make([]string, c.Len())
+		for i := range col {
+			if !na[j].Bit(i) {
+				col[i] = fmt.Sprint(c.Index(i).Interface())
+			}
+			// empty string in golden file is NA
+			na[j].Set(i, col[i] == "")
+		}
+		columns[j] = reflect.ValueOf(col)
+	}
+	return tables.MakeTable(raw.Names, columns, na, raw.Length)
+}
+
+/*
+textual returns type of column in golden file
+*/
+func textual(tp reflect.Type) reflect.Type {
+	switch tp.Kind() {
+	case reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return tp
+	}
+	return fu.String
+}
+
+func parse(s string, tp reflect.Type) (reflect.Value, error) {
+	switch tp.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(s), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		return reflect.ValueOf(b), err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return reflect.ValueOf(f).Convert(tp), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		return reflect.ValueOf(u).Convert(tp), err
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return reflect.ValueOf(i).Convert(tp), err
+}
@@ -0,0 +1,210 @@
+package tables
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/zorros"
+	"reflect"
+	"sync"
+)
+
+// DefaultTeeBuffer is the count of rows Tee buffers for slow consumers by default
+const DefaultTeeBuffer = 1024
+
+/*
+DrainAll drains stream into several sinks reading the source once
+
+	err := source.DrainAll(csv.Sink(iokit.File("out.csv")), rdb.Sink("sqlite3:file:out.db", rdb.Table("out")))
+
+Every row is written into all sinks, if one of sinks fails, all sinks get the false end-marker.
+*/
+func (zf Lazy) DrainAll(sinks ...Sink) error {
+	return zf.Drain(func(v reflect.Value) (err error) {
+		if v.Kind() == reflect.Bool {
+			for _, sink := range sinks {
+				err = fu.Fnze(err, sink(v))
+			}
+			return
+		}
+		for _, sink := range sinks {
+			if err = sink(v); err != nil {
+				return
+			}
+		}
+		return
+	})
+}
+
+/*
+Tee duplicates stream into n streams sharing one source
+
+	zx := source.Tee(2)
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() { defer wg.Done(); e1 = zx[0].Drain(csv.Sink(iokit.File("out.csv"))) }()
+	go func() { defer wg.Done(); e2 = zx[1].Parallel().Map(f).Drain(sink) }()
+	wg.Wait()
+
+Streams have to be consumed concurrently, the faster stream waits the slower one
+when it's ahead by more than buffer rows (DefaultTeeBuffer by default).
+Every stream has to be read to the end, streams get the end of source only when all of them reach it,
+so if one of them is stopped earlier or fails, other streams fail too and their sinks get the false end-marker.
+Streams can be drained only once.
+*/
+func (zf Lazy) Tee(n int, buffer ...int) []Lazy {
+	t := &tee{
+		source:   zf,
+		capacity: DefaultTeeBuffer,
+		pos:      make([]uint64, n),
+		done:     make([]bool, n),
+		closed:   make([]bool, n),
+	}
+	if len(buffer) > 0 {
+		t.capacity = fu.Maxi(1, buffer[0])
+	}
+	t.cond = sync.NewCond(&t.mu)
+	r := make([]Lazy, n)
+	for i := range r {
+		k := i
+		r[i] = func() lazy.Stream { return t.stream(k) }
+	}
+	return r
+}
+
+type tee struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	source   Lazy
+	z        lazy.Stream
+	capacity int
+	buf      []reflect.Value
+	base     uint64   // source index of buf[0]
+	pos      []uint64 // the next index of every consumer
+	done     []bool   // consumer reached the end or stopped
+	closed   []bool   // consumer is stopped
+	reading  bool
+	end      bool
+	err      error
+	failed   error
+	ended    int // count of consumers reached the end
+	stopped  int
+}
+
+func (t *tee) stream(k int) lazy.Stream {
+	wc := fu.WaitCounter{Value: 0}
+	return func(index uint64) (reflect.Value, error) {
+		if index == lazy.STOP {
+			wc.Stop()
+			t.stop(k)
+			return reflect.ValueOf(false), nil
+		}
+		if !wc.Wait(index) {
+			return reflect.ValueOf(false), nil
+		}
+		v, err := t.fetch(k, index)
+		if err != nil || (v.Kind() == reflect.Bool && !v.Bool()) {
+			wc.Stop()
+		} else {
+			wc.Inc()
+		}
+		return v, err
+	}
+}
+
+func (t *tee) fetch(k int, index uint64) (reflect.Value, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for {
+		if t.failed != nil {
+			return reflect.ValueOf(false), t.failed
+		}
+		if index < t.base+uint64(len(t.buf)) {
+			v := t.buf[index-t.base]
+			t.pos[k] = index + 1
+			t.trim()
+			return v, nil
+		}
+		if t.end {
+			if !t.done[k] {
+				t.done[k] = true
+				t.ended++
+				t.trim()
+				t.cond.Broadcast()
+			}
+			if t.err != nil || t.ended == len(t.pos) {
+				return reflect.ValueOf(false), t.err
+			}
+			// the end-marker is delivered when all streams reach the end
+			t.cond.Wait()
+			continue
+		}
+		if t.reading || len(t.buf) >= t.capacity {
+			t.cond.Wait()
+			continue
+		}
+		t.read()
+	}
+}
+
+/*
+read reads the next value from source releasing the lock while reading
+*/
+func (t *tee) read() {
+	if t.z == nil {
+		t.z = t.source()
+	}
+	t.reading = true
+	i := t.base + uint64(len(t.buf))
+	t.mu.Unlock()
+	v, err := t.z(i)
+	t.mu.Lock()
+	t.reading = false
+	if err != nil || (v.Kind() == reflect.Bool && !v.Bool()) {
+		t.end, t.err = true, err
+	} else {
+		t.buf = append(t.buf, v)
+	}
+	t.cond.Broadcast()
+}
+
+/*
+trim drops values all active consumers have already fetched
+*/
+func (t *tee) trim() {
+	min := t.base + uint64(len(t.buf))
+	for i, p := range t.pos {
+		if !t.done[i] && p < min {
+			min = p
+		}
+	}
+	if min > t.base {
+		n := int(min - t.base)
+		t.buf = t.buf[n:]
+		t.base = min
+		t.cond.Broadcast()
+	}
+}
+
+func (t *tee) stop(k int) {
+	t.mu.Lock()
+	if t.closed[k] {
+		t.mu.Unlock()
+		return
+	}
+	if !t.done[k] && t.failed == nil {
+		t.failed = zorros.Errorf("tee stream %d is stopped before the end of source", k)
+	}
+	t.done[k], t.closed[k] = true, true
+	t.stopped++
+	t.trim()
+	t.cond.Broadcast()
+	last := t.stopped == len(t.pos)
+	for last && t.reading {
+		t.cond.Wait()
+	}
+	z := t.z
+	t.mu.Unlock()
+	if last && z != nil {
+		z(lazy.STOP)
+	}
+}
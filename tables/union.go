@@ -0,0 +1,256 @@
+package tables
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/zorros"
+	"reflect"
+)
+
+// UnionMode specifies how LazyUnion and ConcatAligned combine columns of sources
+type UnionMode int
+
+const (
+	// UnionAll keeps columns of all sources, missing values are NA
+	UnionAll UnionMode = iota
+	// UnionCommon keeps only columns existing in all sources
+	UnionCommon
+	// UnionStrict requires the same columns in all sources, possibly in different orders
+	UnionStrict
+)
+
+/*
+LazyUnion concatenates streams having different columns or different orders of columns
+
+	q := tables.LazyUnion(
+		csv.Source(iokit.File("2019.csv"), csv.Int("Id"), csv.Float32("Rate")),
+		csv.Source(iokit.File("2020.csv"), csv.Float64("Rate"), csv.Int("Id"), csv.String("Comment")),
+		tables.UnionAll).LuckyCollect()
+	// q has columns Id int, Rate float64 and Comment string with NA in rows from 2019.csv
+
+Sources can be Lazy streams or tables, columns go in order of the first appearance.
+Columns of different numeric types are widened, different integer types become int64 (or uint64 if both are unsigned),
+float32 and float64 become float64, integer and float become float64.
+The first row of every source is read when stream starts to compute the common schema,
+so all sources are open at the same time.
+*/
+func LazyUnion(sources ...interface{}) Lazy {
+	mode := UnionMode(fu.IntOption(UnionAll, sources))
+	zx := []Lazy{}
+	for _, s := range sources {
+		switch x := s.(type) {
+		case UnionMode:
+		case AnyData:
+			zx = append(zx, x.Lazy())
+		default:
+			panic(zorros.Panic(zorros.Errorf("union source can't be %v", reflect.TypeOf(s))))
+		}
+	}
+	return func() lazy.Stream {
+		u := &union{mode: mode, zs: make([]lazy.Stream, len(zx))}
+		for i, z := range zx {
+			u.zs[i] = z()
+		}
+		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
+		return func(index uint64) (v reflect.Value, err error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				if f.Set() {
+					for _, z := range u.zs {
+						z(lazy.STOP)
+					}
+				}
+				return reflect.ValueOf(false), nil
+			}
+			if !wc.Wait(index) {
+				return reflect.ValueOf(false), nil
+			}
+			if v, err = u.next(); err != nil || v.Kind() == reflect.Bool {
+				wc.Stop()
+				return reflect.ValueOf(false), err
+			}
+			wc.Inc()
+			return
+		}
+	}
+}
+
+/*
+ConcatAligned concatenates tables aligning their columns like LazyUnion does
+*/
+func (t *Table) ConcatAligned(a *Table, mode ...UnionMode) *Table {
+	m := UnionAll
+	if len(mode) > 0 {
+		m = mode[0]
+	}
+	return LazyUnion(t, a, m).LuckyCollect()
+}
+
+type union struct {
+	mode    UnionMode
+	zs      []lazy.Stream
+	started bool
+	first   []*fu.Struct // the first row of source if it's not delivered yet
+	ended   []bool
+	offs    []uint64 // the next index of source
+	k       int      // the current source
+	names   []string
+	types   []reflect.Type
+	pos     [][]int // positions of columns in rows of source, -1 if column is missing
+}
+
+func (u *union) next() (v reflect.Value, err error) {
+	if !u.started {
+		u.started = true
+		if err = u.start(); err != nil {
+			return
+		}
+	}
+	for u.k < len(u.zs) {
+		k := u.k
+		if u.first[k] != nil {
+			lr := *u.first[k]
+			u.first[k] = nil
+			return reflect.ValueOf(u.align(lr, k)), nil
+		}
+		if u.ended[k] {
+			u.k++
+			continue
+		}
+		if v, err = u.zs[k](u.offs[k]); err != nil {
+			return
+		}
+		u.offs[k]++
+		if v.Kind() == reflect.Bool {
+			u.ended[k] = !v.Bool()
+			continue
+		}
+		return reflect.ValueOf(u.align(v.Interface().(fu.Struct), k)), nil
+	}
+	return reflect.ValueOf(false), nil
+}
+
+/*
+start reads the first rows of all sources and computes the common schema
+*/
+func (u *union) start() (err error) {
+	n := len(u.zs)
+	u.first, u.ended, u.offs = make([]*fu.Struct, n), make([]bool, n), make([]uint64, n)
+	for k, z := range u.zs {
+		for {
+			var v reflect.Value
+			if v, err = z(u.offs[k]); err != nil {
+				return
+			}
+			u.offs[k]++
+			if v.Kind() != reflect.Bool {
+				lr := v.Interface().(fu.Struct)
+				u.first[k] = &lr
+				break
+			} else if !v.Bool() {
+				u.ended[k] = true
+				break
+			}
+		}
+	}
+	for k, lr := range u.first {
+		if lr == nil {
+			continue
+		}
+		for i, n := range lr.Names {
+			if !lr.Columns[i].IsValid() {
+				return zorros.Errorf("column %v of source %d does not have type", n, k)
+			}
+			t := lr.Columns[i].Type()
+			if j := fu.IndexOf(n, u.names); j < 0 {
+				u.names = append(u.names, n)
+				u.types = append(u.types, t)
+			} else if u.types[j], err = widen(u.types[j], t); err != nil {
+				return zorros.Wrapf(err, "column %v: %s", n, err.Error())
+			}
+		}
+	}
+	names, types := u.names[:0:0], u.types[:0:0]
+	for j, n := range u.names {
+		missing := false
+		for k, lr := range u.first {
+			if lr != nil && lr.Pos(n) < 0 {
+				if u.mode == UnionStrict {
+					return zorros.Errorf("source %d does not have column %v", k, n)
+				}
+				missing = true
+			}
+		}
+		if !missing || u.mode == UnionAll {
+			names = append(names, n)
+			types = append(types, u.types[j])
+		}
+	}
+	u.names, u.types = names, types
+	u.pos = make([][]int, n)
+	for k, lr := range u.first {
+		if lr != nil {
+			u.pos[k] = make([]int, len(u.names))
+			for j, n := range u.names {
+				u.pos[k][j] = lr.Pos(n)
+			}
+		}
+	}
+	return
+}
+
+func (u *union) align(lr fu.Struct, k int) fu.Struct {
+	r := fu.Struct{Names: u.names, Columns: make([]reflect.Value, len(u.names))}
+	for j, p := range u.pos[k] {
+		if p < 0 {
+			r.Columns[j] = reflect.Zero(u.types[j])
+			r.Na.Set(j, true)
+			continue
+		}
+		v := lr.Columns[p]
+		if v.Type() != u.types[j] {
+			v = v.Convert(u.types[j])
+		}
+		r.Columns[j] = v
+		r.Na.Set(j, lr.Na.Bit(p))
+	}
+	return r
+}
+
+func isInt(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloat(t reflect.Type) bool {
+	return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
+
+/*
+widen returns the type both types can be converted to
+*/
+func widen(a, b reflect.Type) (reflect.Type, error) {
+	switch {
+	case a == b:
+		return a, nil
+	case isUint(a) && isUint(b):
+		return fu.Uint64, nil
+	case (isInt(a) || isUint(a)) && (isInt(b) || isUint(b)):
+		return fu.Int64, nil
+	case (isFloat(a) || isInt(a) || isUint(a)) && (isFloat(b) || isInt(b) || isUint(b)):
+		return fu.Float64, nil
+	}
+	return nil, zorros.Errorf("incompatible types %v and %v", a, b)
+}
@@ -0,0 +1,386 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"go4ml.xyz/zorros"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type xlsxRels struct {
+	Rels []struct {
+		Id     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x *xlsxText) String() string {
+	if len(x.R) == 0 {
+		return x.T
+	}
+	s := make([]string, len(x.R))
+	for i, r := range x.R {
+		s[i] = r.T
+	}
+	return strings.Join(s, "")
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		Id   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtId int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxCell struct {
+	R  string    `xml:"r,attr"`
+	T  string    `xml:"t,attr"`
+	S  int       `xml:"s,attr"`
+	V  string    `xml:"v"`
+	Is *xlsxText `xml:"is"`
+}
+
+type sheetRef struct {
+	name, path string
+}
+
+type workbook struct {
+	files    map[string]*zip.File
+	sheets   []sheetRef
+	strings  []string
+	dates    []bool // cell styles formatting dates
+	date1904 bool
+}
+
+func openWorkbook(zr *zip.Reader) (wb *workbook, err error) {
+	wb = &workbook{files: map[string]*zip.File{}}
+	for _, f := range zr.File {
+		wb.files[f.Name] = f
+	}
+	wbpath := "xl/workbook.xml"
+	rels := xlsxRels{}
+	if err = wb.decode("_rels/.rels", &rels, true); err != nil {
+		return
+	}
+	for _, r := range rels.Rels {
+		if strings.HasSuffix(r.Type, "/officeDocument") {
+			wbpath = strings.TrimPrefix(r.Target, "/")
+		}
+	}
+	x := xlsxWorkbook{}
+	if err = wb.decode(wbpath, &x, false); err != nil {
+		return
+	}
+	wb.date1904 = x.WorkbookPr.Date1904
+	dir := path.Dir(wbpath)
+	rels = xlsxRels{}
+	if err = wb.decode(path.Join(dir, "_rels", path.Base(wbpath)+".rels"), &rels, false); err != nil {
+		return
+	}
+	target := func(tp string, id string) string {
+		for _, r := range rels.Rels {
+			if (id == "" || r.Id == id) && strings.HasSuffix(r.Type, tp) {
+				if strings.HasPrefix(r.Target, "/") {
+					return r.Target[1:]
+				}
+				return path.Join(dir, r.Target)
+			}
+		}
+		return ""
+	}
+	for _, s := range x.Sheets {
+		wb.sheets = append(wb.sheets, sheetRef{s.Name, target("/worksheet", s.Id)})
+	}
+	if p := target("/sharedStrings", ""); p != "" {
+		sst := xlsxSharedStrings{}
+		if err = wb.decode(p, &sst, false); err != nil {
+			return
+		}
+		wb.strings = make([]string, len(sst.Items))
+		for i := range sst.Items {
+			wb.strings[i] = sst.Items[i].String()
+		}
+	}
+	if p := target("/styles", ""); p != "" {
+		st := xlsxStyles{}
+		if err = wb.decode(p, &st, false); err != nil {
+			return
+		}
+		custom := map[int]string{}
+		for _, f := range st.NumFmts {
+			custom[f.Id] = f.Code
+		}
+		wb.dates = make([]bool, len(st.CellXfs))
+		for i, xf := range st.CellXfs {
+			if code, ok := custom[xf.NumFmtId]; ok {
+				wb.dates[i] = isDateFormat(code)
+			} else {
+				wb.dates[i] = isDateFormatId(xf.NumFmtId)
+			}
+		}
+	}
+	return
+}
+
+func (wb *workbook) decode(name string, x interface{}, optional bool) error {
+	f, ok := wb.files[name]
+	if !ok {
+		if optional {
+			return nil
+		}
+		return zorros.Errorf("xlsx file does not have %v", name)
+	}
+	rd, err := f.Open()
+	if err != nil {
+		return zorros.Wrapf(err, "failed to open %v: %s", name, err.Error())
+	}
+	defer rd.Close()
+	if err = xml.NewDecoder(rd).Decode(x); err != nil {
+		return zorros.Wrapf(err, "failed to decode %v: %s", name, err.Error())
+	}
+	return nil
+}
+
+func (wb *workbook) openSheet(name string, index int) (*sheetReader, error) {
+	if name != "" {
+		index = -1
+		for i, s := range wb.sheets {
+			if s.name == name {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, zorros.Errorf("worksheet %v does not exist", name)
+		}
+	} else if index < 0 || index >= len(wb.sheets) {
+		return nil, zorros.Errorf("worksheet index %v is out of range", index)
+	}
+	f, ok := wb.files[wb.sheets[index].path]
+	if !ok {
+		return nil, zorros.Errorf("xlsx file does not have worksheet %v", wb.sheets[index].name)
+	}
+	rd, err := f.Open()
+	if err != nil {
+		return nil, zorros.Wrapf(err, "failed to open worksheet: %s", err.Error())
+	}
+	return &sheetReader{wb, rd, xml.NewDecoder(rd)}, nil
+}
+
+type sheetReader struct {
+	wb  *workbook
+	rd  io.ReadCloser
+	dec *xml.Decoder
+}
+
+func (s *sheetReader) Close() error {
+	return s.rd.Close()
+}
+
+/*
+next returns text values of the next not empty row or io.EOF
+*/
+func (s *sheetReader) next() (vals []string, err error) {
+	for {
+		var tok xml.Token
+		if tok, err = s.dec.Token(); err != nil {
+			return
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "row" {
+			if vals, err = s.row(); err != nil || len(vals) > 0 {
+				return
+			}
+		}
+	}
+}
+
+func (s *sheetReader) row() (vals []string, err error) {
+	col := 0
+	for {
+		var tok xml.Token
+		if tok, err = s.dec.Token(); err != nil {
+			if err == io.EOF {
+				err = zorros.Errorf("worksheet is truncated")
+			}
+			return
+		}
+		switch e := tok.(type) {
+		case xml.StartElement:
+			if e.Name.Local != "c" {
+				continue
+			}
+			c := xlsxCell{}
+			if err = s.dec.DecodeElement(&c, &e); err != nil {
+				return nil, zorros.Wrapf(err, "failed to decode cell: %s", err.Error())
+			}
+			if c.R != "" {
+				if col, err = columnIndex(c.R); err != nil {
+					return
+				}
+			}
+			var v string
+			if v, err = s.value(&c); err != nil {
+				return
+			}
+			if v != "" {
+				for len(vals) < col {
+					vals = append(vals, "")
+				}
+				vals = append(vals[:col], v)
+			}
+			col++
+		case xml.EndElement:
+			if e.Name.Local == "row" {
+				return
+			}
+		}
+	}
+}
+
+func (s *sheetReader) value(c *xlsxCell) (string, error) {
+	switch c.T {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(c.V))
+		if err != nil || i < 0 || i >= len(s.wb.strings) {
+			return "", zorros.Errorf("invalid shared string index %v in cell %v", c.V, c.R)
+		}
+		return s.wb.strings[i], nil
+	case "inlineStr":
+		if c.Is != nil {
+			return c.Is.String(), nil
+		}
+		return "", nil
+	case "b":
+		if strings.TrimSpace(c.V) == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	case "e":
+		// error values like #DIV/0! are NA
+		return "", nil
+	case "str", "d":
+		return c.V, nil
+	}
+	if c.V != "" && c.S >= 0 && c.S < len(s.wb.dates) && s.wb.dates[c.S] {
+		f, err := strconv.ParseFloat(c.V, 64)
+		if err != nil {
+			return "", zorros.Errorf("invalid date value %v in cell %v", c.V, c.R)
+		}
+		return serialTime(f, s.wb.date1904).Format(time.RFC3339), nil
+	}
+	return c.V, nil
+}
+
+var epoch1900 = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+var epoch1904 = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+/*
+serialTime converts excel serial date number to time, fractional part is time of day
+*/
+func serialTime(f float64, date1904 bool) time.Time {
+	epoch := epoch1900
+	if date1904 {
+		epoch = epoch1904
+	}
+	days := math.Floor(f)
+	secs := math.Round((f - days) * 86400)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+}
+
+/*
+timeSerial converts time to excel serial date number
+*/
+func timeSerial(t time.Time) float64 {
+	t = t.UTC()
+	return float64(t.Sub(epoch1900)) / float64(24*time.Hour)
+}
+
+func isDateFormatId(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+/*
+isDateFormat detects custom date formats ignoring quoted text, escaped characters and [] sections
+*/
+func isDateFormat(code string) bool {
+	quoted, bracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case quoted:
+			quoted = c != '"'
+		case bracket:
+			bracket = c != ']'
+		case c == '"':
+			quoted = true
+		case c == '[':
+			bracket = true
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case strings.IndexByte("dmyhsDMYHS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+/*
+columnIndex returns index of column referenced by cell reference like AB12
+*/
+func columnIndex(ref string) (int, error) {
+	n, i := 0, 0
+	for ; i < len(ref); i++ {
+		c := ref[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		n = n*26 + int(c-'A') + 1
+	}
+	if i == 0 {
+		return 0, zorros.Errorf("invalid cell reference %v", ref)
+	}
+	return n - 1, nil
+}
+
+/*
+columnName returns excel column name like AB by index starting from 0
+*/
+func columnName(i int) string {
+	s := []byte{}
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = append([]byte{byte('A' + (i-1)%26)}, s...)
+	}
+	return string(s)
+}
@@ -0,0 +1,182 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables/csv"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const contentTypesXml = xmlHeader +
+	`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const relsXml = xmlHeader +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRelsXml = xmlHeader +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// the second cell style formats dates, it's used for time.Time values
+const stylesXml = xmlHeader +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+const sheetHeaderXml = xmlHeader +
+	`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetFooterXml = `</sheetData></worksheet>`
+
+/*
+workbookWriter writes single worksheet workbook, rows are streamed into the worksheet
+and other workbook parts are written on close
+*/
+type workbookWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	name  string
+	rows  int
+	buf   bytes.Buffer
+	err   error
+}
+
+func newWorkbookWriter(w io.Writer, name string) *workbookWriter {
+	return &workbookWriter{zw: zip.NewWriter(w), name: name}
+}
+
+func (w *workbookWriter) begin() {
+	if w.sheet == nil && w.err == nil {
+		if w.sheet, w.err = w.zw.Create("xl/worksheets/sheet1.xml"); w.err == nil {
+			_, w.err = io.WriteString(w.sheet, sheetHeaderXml)
+		}
+	}
+}
+
+func (w *workbookWriter) flush() error {
+	if w.err == nil {
+		_, w.err = w.sheet.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return w.err
+}
+
+func (w *workbookWriter) header(names []string) error {
+	w.begin()
+	w.rows++
+	w.buf.WriteString(`<row r="1">`)
+	for i, n := range names {
+		w.text(i, n)
+	}
+	w.buf.WriteString(`</row>`)
+	return w.flush()
+}
+
+func (w *workbookWriter) row(lr fu.Struct, fs *csv.Fields) error {
+	w.rows++
+	w.buf.WriteString(`<row r="` + strconv.Itoa(w.rows) + `">`)
+	for i, v := range lr.Columns {
+		if lr.Na.Bit(i) {
+			continue
+		}
+		switch {
+		case v.Type() == fu.Ts:
+			w.cell(i, ` s="1"`, strconv.FormatFloat(timeSerial(v.Interface().(time.Time)), 'f', -1, 64))
+		case v.Kind() == reflect.Bool && !fs.Formatted(i):
+			x := "0"
+			if v.Bool() {
+				x = "1"
+			}
+			w.cell(i, ` t="b"`, x)
+		case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+			if f := v.Float(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+				w.cell(i, "", fs.Format(i, lr))
+			}
+		case isInteger(v.Kind()) || v.Type() == fu.Fixed8Type:
+			w.cell(i, "", fs.Format(i, lr))
+		default:
+			w.text(i, fs.Format(i, lr))
+		}
+	}
+	w.buf.WriteString(`</row>`)
+	return w.flush()
+}
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func (w *workbookWriter) ref(i int) string {
+	return columnName(i) + strconv.Itoa(w.rows)
+}
+
+func (w *workbookWriter) cell(i int, attrs string, v string) {
+	w.buf.WriteString(`<c r="` + w.ref(i) + `"` + attrs + `><v>` + v + `</v></c>`)
+}
+
+func (w *workbookWriter) text(i int, s string) {
+	w.buf.WriteString(`<c r="` + w.ref(i) + `" t="inlineStr"><is><t xml:space="preserve">`)
+	_ = xml.EscapeText(&w.buf, []byte(s))
+	w.buf.WriteString(`</t></is></c>`)
+}
+
+func (w *workbookWriter) close() error {
+	w.begin()
+	if w.err == nil {
+		_, w.err = io.WriteString(w.sheet, sheetFooterXml)
+	}
+	name := bytes.Buffer{}
+	_ = xml.EscapeText(&name, []byte(w.name))
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypesXml},
+		{"_rels/.rels", relsXml},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXml},
+		{"xl/styles.xml", stylesXml},
+		{"xl/workbook.xml", xmlHeader +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	}
+	for _, p := range parts {
+		if w.err != nil {
+			break
+		}
+		var f io.Writer
+		if f, w.err = w.zw.Create(p.name); w.err == nil {
+			_, w.err = io.WriteString(f, p.content)
+		}
+	}
+	if w.err == nil {
+		w.err = w.zw.Close()
+	}
+	return w.err
+}
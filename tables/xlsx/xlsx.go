@@ -0,0 +1,186 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"go4ml.xyz/zorros"
+	"io"
+	"io/ioutil"
+	"reflect"
+)
+
+// Sheet selects worksheet by name
+type Sheet string
+
+// SheetIndex selects worksheet by index starting from 0
+type SheetIndex int
+
+/*
+Read reads worksheet into table using csv resolvers as column options
+
+	// reads the first worksheet
+	xlsx.Read(iokit.File("file.xlsx"))
+
+	// reads worksheet by name
+	xlsx.Read(iokit.File("file.xlsx"),
+		xlsx.Sheet("Sales"),
+		csv.Float32("price").As("Price"),
+		csv.Time("date").As("Date"))
+
+	// reads the second worksheet
+	xlsx.Read(iokit.File("file.xlsx"), xlsx.SheetIndex(1))
+
+The first row of worksheet is the header, empty cells are NA.
+Cells formatted as dates are converted to RFC3339 text,
+so they are strings if there is no csv.Time resolver for the column.
+*/
+func Read(source interface{}, opts ...interface{}) (t *tables.Table, err error) {
+	return Source(source, opts...).Collect()
+}
+
+func Source(source interface{}, opts ...interface{}) tables.Lazy {
+	if e, ok := source.(iokit.Input); ok {
+		return lazyread(e, opts...)
+	} else if e, ok := source.(string); ok {
+		return lazyread(iokit.File(e), opts...)
+	} else if rd, ok := source.(io.Reader); ok {
+		return lazyread(iokit.Reader(rd, nil), opts...)
+	}
+	return tables.SourceError(zorros.Errorf("xlsx reader does not know source type %v", reflect.TypeOf(source).String()))
+}
+
+func lazyread(source iokit.Input, opts ...interface{}) tables.Lazy {
+	return func() lazy.Stream {
+		rd, err := source.Open()
+		if err != nil {
+			return lazy.Error(err)
+		}
+		// zip archive requires random access, so workbook is read into memory
+		bs, err := ioutil.ReadAll(rd)
+		rd.Close()
+		if err != nil {
+			return lazy.Error(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+		if err != nil {
+			return lazy.Error(zorros.Wrapf(err, "xlsx file is corrupted: %s", err.Error()))
+		}
+		wb, err := openWorkbook(zr)
+		if err != nil {
+			return lazy.Error(err)
+		}
+		sh, err := wb.openSheet(fu.StrOption(Sheet(""), opts), fu.IntOption(SheetIndex(0), opts))
+		if err != nil {
+			return lazy.Error(err)
+		}
+		header, err := sh.next()
+		if err != nil {
+			sh.Close()
+			if err == io.EOF {
+				err = zorros.Errorf("worksheet is empty")
+			}
+			return lazy.Error(err)
+		}
+		for i, n := range header {
+			if n == "" {
+				header[i] = columnName(i)
+			}
+		}
+		fs, err := csv.MapFields(header, opts)
+		if err != nil {
+			sh.Close()
+			return lazy.Error(err)
+		}
+
+		wc := fu.WaitCounter{Value: 0}
+		f := fu.AtomicFlag{Value: 0}
+		return func(index uint64) (reflect.Value, error) {
+			if index == lazy.STOP {
+				wc.Stop()
+				if f.Set() {
+					sh.Close()
+				}
+				return reflect.ValueOf(false), nil
+			}
+			if !wc.Wait(index) {
+				return reflect.ValueOf(false), nil
+			}
+			vals, err := sh.next()
+			if err == nil {
+				lr := fs.Row()
+				for i := range header {
+					v := ""
+					if i < len(vals) {
+						v = vals[i]
+					}
+					if err = fs.Convert(i, v, &lr); err != nil {
+						wc.Inc()
+						return reflect.ValueOf(false), &tables.RowError{Row: csv.RawRow(header, vals), Err: err}
+					}
+					if v == "" && !fs.Group(i) {
+						lr.Na.Set(fs.Field(i), true)
+					}
+				}
+				wc.Inc()
+				return reflect.ValueOf(lr), nil
+			}
+			wc.Stop()
+			if f.Set() {
+				sh.Close()
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			return reflect.ValueOf(false), err
+		}
+	}
+}
+
+/*
+Write writes table into the only worksheet of new workbook, the worksheet is Sheet1 by default
+
+	xlsx.Write(t, iokit.File("file.xlsx"))
+
+	xlsx.Write(t, iokit.File("file.xlsx"),
+		xlsx.Sheet("Results"),
+		csv.Column("feature_1").Round(2).As("Feature1"))
+*/
+func Write(t *tables.Table, dest iokit.Output, opts ...interface{}) (err error) {
+	return t.Lazy().Drain(Sink(dest, opts...))
+}
+
+func Sink(dest iokit.Output, opts ...interface{}) tables.Sink {
+	var err error
+	f := iokit.Whole(nil)
+	if f, err = dest.Create(); err != nil {
+		return tables.SinkError(err)
+	}
+	w := newWorkbookWriter(f, fu.StrOption(Sheet("Sheet1"), opts))
+	var fs *csv.Fields
+	return func(v reflect.Value) (err error) {
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				if err = w.close(); err == nil {
+					err = f.Commit()
+				}
+			}
+			f.End()
+			return
+		}
+		lr := v.Interface().(fu.Struct)
+		if fs == nil {
+			if fs, err = csv.MapFields(lr.Names, opts); err != nil {
+				return
+			}
+			if err = w.header(fs.Names); err != nil {
+				return
+			}
+		}
+		return w.row(lr, fs)
+	}
+}
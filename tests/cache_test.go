@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"gotest.tools/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Cache1(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tr.rows")
+
+	calls := int32(0)
+	source := TrTable().Lazy().Map(func(r TR) TR { atomic.AddInt32(&calls, 1); return r })
+
+	q, err := source.Cache(path).First(2).Collect()
+	assert.NilError(t, err)
+	assert.Equal(t, q.Len(), 2)
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err), "stopped iteration does not write cache")
+
+	for i := 0; i < 3; i++ {
+		atomic.StoreInt32(&calls, 0)
+		q, err = source.Cache(path).Parallel().Collect()
+		assert.NilError(t, err)
+		assertTrData(t, q)
+		assert.Equal(t, int(atomic.LoadInt32(&calls)), fu.Ifei(i == 0, len(trList), 0))
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	q, err = source.Cache(path, tables.Fingerprint("v2")).Collect()
+	assert.NilError(t, err)
+	assertTrData(t, q)
+	assert.Equal(t, int(atomic.LoadInt32(&calls)), len(trList))
+	ls, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, len(ls), 1)
+}
+
+func Test_Cache2(t *testing.T) {
+	const N = 10
+	ts := make([]fu.Tensor, N)
+	for i := range ts {
+		ts[i] = fu.MakeFloat32Tensor(1, 2, 3, []float32{float32(i), 1, 2, 3, 4, 5})
+	}
+	na := fu.Bits{}
+	na.Set(3, true)
+	q := tables.MakeTable(
+		[]string{"Tensor", "Label"},
+		[]reflect.Value{reflect.ValueOf(ts), reflect.ValueOf(make([]int, N))},
+		[]fu.Bits{{}, na},
+		N)
+
+	cached := q.Lazy().Cache("")
+	for i := 0; i < 2; i++ {
+		r, err := cached.Collect()
+		assert.NilError(t, err)
+		assert.Equal(t, r.Len(), N)
+		assert.Assert(t, r.Col("Label").Na(3))
+		assert.Assert(t, !r.Col("Label").Na(4))
+		x := r.Col("Tensor").Tensor(7)
+		assert.Equal(t, x.Width(), 3)
+		assert.DeepEqual(t, x.Values(), []float32{7, 1, 2, 3, 4, 5})
+	}
+}
+
+func Test_Cache3(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-test-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	tmpdir := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", dir)
+	defer os.Setenv("TMPDIR", tmpdir)
+
+	func() {
+		cached := TrTable().Lazy().Cache("")
+		for i := 0; i < 2; i++ {
+			r, err := cached.Collect()
+			assert.NilError(t, err)
+			assert.Equal(t, r.Len(), TrTable().Len())
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "go4ml-cache-*.rows"))
+		assert.Equal(t, len(files), 1)
+	}()
+
+	// temporary cache file is removed when cached stream is collected
+	files := []string{}
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+		if files, _ = filepath.Glob(filepath.Join(dir, "*")); len(files) == 0 {
+			break
+		}
+	}
+	assert.Equal(t, len(files), 0, "%v", files)
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"gotest.tools/assert"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_ColOps1(t *testing.T) {
+	q := PrepareTable(t)
+	s := q.Col("Rate").Mul(q.Col("Age")).Add(1)
+	assert.Equal(t, s.Type(), fu.Float64)
+	q = q.With(s, "Score")
+	assert.DeepEqual(t, q.Col("Score").Reals(), []float32{float32(1.2*32 + 1), float32(1.5*44 + 1)})
+
+	r := q.Col("Rate").Sub(0.2).Div(2)
+	assert.Equal(t, r.Type(), fu.Float32)
+	assert.DeepEqual(t, r.Reals(), []float32{0.5, 0.65})
+	assert.DeepEqual(t, q.Col("Age").Sub(40).Abs().Floats(), []float64{8, 4})
+	assert.DeepEqual(t, q.Col("Age").Clip(35, 40).Floats(), []float64{35, 40})
+	assert.Assert(t, math.Abs(q.Col("Age").Log().Float(0)-math.Log(32)) < 1e-9)
+
+	assert.DeepEqual(t, q.Col("Age").Gt(40).Bools(), []bool{false, true})
+	assert.DeepEqual(t, q.Col("Age").Le(q.Col("Score")).Bools(), []bool{true, true})
+	assert.DeepEqual(t, q.Col("Name").Eq("Ivanov").Bools(), []bool{true, false})
+	assert.DeepEqual(t, q.Col("Name").Ne(q.Col("Name")).Bools(), []bool{false, false})
+
+	assert.Equal(t, q.Col("Age").Sum(), 76.0)
+	assert.Equal(t, q.Col("Age").Mean(), 38.0)
+	assert.Equal(t, q.Col("Age").Quantile(0.25), 35.0)
+	assert.Assert(t, math.Abs(q.Col("Age").Std()-math.Sqrt(72)) < 1e-9)
+	assert.Assert(t, math.Abs(q.Col("Age").Corr(q.Col("Rate"))-1) < 1e-6)
+}
+
+func Test_ColOps2(t *testing.T) {
+	na := fu.Bits{}
+	na.Set(1, true)
+	q := tables.MakeTable(
+		[]string{"X", "Name"},
+		[]reflect.Value{reflect.ValueOf([]int{1, 100, 3, 3}), reflect.ValueOf([]string{"a", "b", "b", "b"})},
+		[]fu.Bits{na, {}},
+		4)
+	x := q.Col("X")
+	assert.Equal(t, x.Sum(), 7.0)
+	assert.Equal(t, x.Quantile(1), 3.0)
+	y := x.Mul(x)
+	assert.Assert(t, y.Na(1) && math.IsNaN(y.Float(1)))
+	assert.Equal(t, y.Float(2), 9.0)
+	g := x.Ge(1)
+	assert.DeepEqual(t, g.Bools(), []bool{true, false, true, true})
+	assert.Assert(t, g.Na(1))
+
+	vc := x.ValueCounts()
+	assert.DeepEqual(t, vc.Col("Value").Ints(), []int{3, 1})
+	assert.DeepEqual(t, vc.Col("Count").Ints(), []int{2, 1})
+	vc = q.Col("Name").ValueCounts()
+	assert.DeepEqual(t, vc.Col("Value").Strings(), []string{"b", "a"})
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func noLeaks(t *testing.T, baseline int) {
+	deadline := time.Now().Add(3 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Assert(t, runtime.NumGoroutine() <= baseline, "%d goroutines leaked", runtime.NumGoroutine()-baseline)
+}
+
+func Test_Context1(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	blocked := tables.Lazy(lazy.Chan(make(chan fu.Struct)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	_, err := blocked.WithContext(ctx).Parallel(4).Collect()
+	cancel()
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	_, err = blocked.Parallel(4).WithContext(ctx).Collect()
+	cancel()
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = TrTable().Lazy().WithContext(ctx).Collect()
+	assert.Equal(t, err, context.Canceled)
+
+	q, err := TrTable().Lazy().WithContext(context.Background()).Parallel().Collect()
+	assert.NilError(t, err)
+	assert.Equal(t, q.Len(), TrTable().Len())
+	noLeaks(t, baseline)
+}
+
+func Test_Context2(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	rd, wr := io.Pipe()
+	written := make(chan error)
+	go func() {
+		_, err := io.WriteString(wr, "Id,Name\n")
+		for i := 0; err == nil; i++ {
+			_, err = io.WriteString(wr, fmt.Sprintf("%d,name%d\n", i, i))
+		}
+		written <- err
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rows := 0
+	out := iokit.StringIO("")
+	sink := csv.Sink(out)
+	err := csv.Source(iokit.Reader(rd, rd), csv.Int("Id")).Parallel(2).DrainContext(ctx, func(v reflect.Value) error {
+		if v.Kind() != reflect.Bool {
+			if rows++; rows == 3 {
+				cancel()
+			}
+		}
+		return sink(v)
+	})
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, out.String(), "")
+	assert.Equal(t, <-written, io.ErrClosedPipe)
+	noLeaks(t, baseline)
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/tablestest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type DiffRow struct {
+	Id   int
+	Name string
+	Rate float64
+	Age  *int
+}
+
+func diffTable() *tables.Table {
+	age := 32
+	return tables.New([]DiffRow{{1, "Ivanov", 1.2, &age}, {2, "Petrov", 1.5, nil}, {3, "Sidorov", 0.7, nil}})
+}
+
+func Test_Diff1(t *testing.T) {
+	a := diffTable()
+	assert.Assert(t, tables.Equal(a, diffTable()))
+	// column order does not matter
+	assert.Assert(t, tables.Equal(a, a.Only("Rate", "Name", "Id", "Age")))
+
+	b := a.Without("Rate").With(a.Col("Rate").Add(1e-7), "Rate")
+	assert.Assert(t, !tables.Equal(a, b))
+	assert.Assert(t, tables.Equal(a, b, tables.Tolerance(1e-6)))
+
+	d := tables.Diff(a, a.Without("Rate").With(a.Col("Rate").Mul(2), "Rate").Without("Age"))
+	assert.DeepEqual(t, d.Removed, []string{"Age"})
+	assert.Equal(t, len(d.Changes), 3)
+	assert.Equal(t, d.Changes[0].Column, "Rate")
+	assert.Equal(t, d.Changes[0].B, 2.4)
+	assert.Assert(t, strings.Contains(d.String(), "- column Age"))
+}
+
+func Test_Diff2(t *testing.T) {
+	a := diffTable()
+	age := 44
+	b := tables.New([]DiffRow{{3, "Sidorov", 0.7, nil}, {2, "Petrov", 1.5, &age}, {4, "Smirnov", 1.1, nil}})
+	d := tables.Diff(a, b, tables.Key("Id"))
+	assert.DeepEqual(t, d.Deletes, []int{0})
+	assert.DeepEqual(t, d.Inserts, []int{2})
+	assert.Equal(t, len(d.Changes), 1)
+	assert.Equal(t, d.Changes[0], tables.CellDiff{Row: 1, Other: 1, Column: "Age", A: nil, B: 44})
+	s := d.String()
+	assert.Assert(t, strings.Contains(s, "~ row 1 (Id=2): Age: N/A => 44"))
+	assert.Assert(t, strings.Contains(s, "+ row 2: "))
+
+	// key missing in one table is a removed column
+	d = tables.Diff(a, b.Without("Id"), tables.Key("Id"))
+	assert.DeepEqual(t, d.Removed, []string{"Id"})
+	assert.Assert(t, len(d.Keys) == 0 && !d.Empty())
+	assert.Assert(t, cmp.Panics(func() { tables.Diff(a, b, tables.Key("Nope")) }))
+
+	// positional matching
+	d = tables.Diff(a, b)
+	assert.Assert(t, len(d.Deletes) == 0 && len(d.Inserts) == 0)
+	assert.Equal(t, len(d.Changes), 8)
+}
+
+type fakeT struct{ failed string }
+
+func (t *fakeT) Helper() {}
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failed = fmt.Sprintf(format, args...)
+}
+
+func Test_Golden1(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "diff.csv")
+	q := diffTable().With(tables.Col([]fu.Tensor{
+		fu.MakeFloat32Tensor(1, 1, 2, []float32{1, 2}),
+		fu.MakeFloat32Tensor(1, 1, 2, []float32{3, 4}),
+		fu.MakeFloat32Tensor(1, 1, 2, []float32{5, 6})}), "Vector")
+
+	tablestest.AssertGolden(t, q, path)
+	bs, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(string(bs), "Id,Name,Rate,Age,Vector\n1,Ivanov,1.2,32,"))
+
+	tablestest.AssertGolden(t, q, path)
+	ft := &fakeT{}
+	tablestest.AssertGolden(ft, q.Without("Rate").With(q.Col("Rate").Add(1e-7), "Rate"), path)
+	assert.Assert(t, strings.Contains(ft.failed, "~ row 0: Rate: 1.2000001 => 1.2"))
+	ft = &fakeT{}
+	tablestest.AssertGolden(ft, q.Without("Rate").With(q.Col("Rate").Add(1e-7), "Rate"), path, tables.Tolerance(1e-6))
+	assert.Equal(t, ft.failed, "")
+
+	ft = &fakeT{}
+	tablestest.AssertEqual(ft, q, q.Without("Vector"))
+	assert.Assert(t, strings.Contains(ft.failed, "- column Vector"))
+}
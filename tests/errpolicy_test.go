@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"strings"
+	"testing"
+)
+
+const badCSV = `Id,Rate
+1,1.5
+2,x
+3,3.5
+4,y
+5,5.5
+`
+
+func Test_ErrorPolicy1(t *testing.T) {
+	source := csv.Source(iokit.StringIO(badCSV), csv.Int("Id"), csv.Float32("Rate"))
+
+	_, err := source.Collect()
+	assert.ErrorContains(t, err, "x")
+
+	summary := &tables.ErrorSummary{}
+	q, err := source.OnError(tables.ErrorPolicy{Skip: true}, summary).Collect()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Col("Id").Ints(), []int{1, 3, 5})
+	assert.Equal(t, summary.Skipped, 2)
+	assert.Equal(t, len(summary.Samples), 2)
+	assert.Equal(t, summary.Samples[0].Row.Text("Rate"), "x")
+
+	_, err = source.OnError(tables.ErrorPolicy{Skip: true, MaxErrors: 1}).Collect()
+	assert.ErrorContains(t, err, "more than 1 bad rows")
+
+	dead := iokit.StringIO("")
+	q, err = source.Parallel().OnError(tables.ErrorPolicy{DeadLetter: csv.Sink(dead)}).Collect()
+	assert.NilError(t, err)
+	assert.Equal(t, q.Len(), 3)
+	x, err := csv.Read(iokit.StringIO(dead.String()), csv.Int("Index"), csv.String("Error"), csv.String("Row"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Col("Index").Ints(), []int{1, 3})
+	assert.Assert(t, strings.Contains(x.Col("Row").Text(1), "y"))
+	assert.Assert(t, strings.Contains(x.Col("Error").Text(0), "x"))
+}
+
+func Test_ErrorPolicy2(t *testing.T) {
+	validate := func(lr fu.Struct) (fu.Struct, bool, error) {
+		if lr.Int("Age")%2 == 0 {
+			return lr, false, &tables.RowError{Err: fmt.Errorf("even age %d", lr.Int("Age"))}
+		}
+		return lr, true, nil
+	}
+	dead := iokit.StringIO("")
+	summary := &tables.ErrorSummary{}
+	q, err := TrTable().Lazy().Transform(validate).OnError(tables.ErrorPolicy{DeadLetter: csv.Sink(dead)}, summary).Collect()
+	assert.NilError(t, err)
+	assert.Assert(t, q.Len()+summary.Skipped == TrTable().Len())
+	assert.ErrorContains(t, summary.Samples[0], "even age")
+	assert.Assert(t, summary.Samples[0].Row.Int("Age")%2 == 0)
+	assert.Assert(t, len(dead.String()) > 0)
+
+	_, err = TrTable().Lazy().Transform(validate).Collect()
+	assert.ErrorContains(t, err, "even age")
+
+	fail := func(lr fu.Struct) (fu.Struct, bool, error) {
+		if lr.Int("Age")%2 == 0 {
+			return lr, false, fmt.Errorf("even age %d", lr.Int("Age"))
+		}
+		return lr, true, nil
+	}
+	_, err = TrTable().Lazy().Transform(fail).OnError(tables.ErrorPolicy{Skip: true}).Collect()
+	assert.ErrorContains(t, err, "even age")
+}
+
+func Test_ErrorPolicy3(t *testing.T) {
+	source := csv.Source(iokit.StringIO(badCSV), csv.Int("Id"), csv.Float32("Rate"))
+	deadLetter := func(dead fmt.Stringer) *tables.Table {
+		x, err := csv.Read(iokit.StringIO(dead.String()), csv.Int("Index"), csv.String("Error"), csv.String("Row"))
+		assert.NilError(t, err)
+		return x
+	}
+
+	for i := 0; i < 10; i++ {
+		dead := iokit.StringIO("")
+		summary := &tables.ErrorSummary{}
+		q, err := source.OnError(tables.ErrorPolicy{DeadLetter: csv.Sink(dead)}, summary).Parallel(4).Collect()
+		assert.NilError(t, err)
+		assert.NilError(t, summary.Err)
+		assert.DeepEqual(t, q.Col("Id").Ints(), []int{1, 3, 5})
+		x := deadLetter(dead)
+		assert.DeepEqual(t, x.Col("Index").Ints(), []int{1, 3})
+		assert.Assert(t, strings.Contains(x.Col("Error").Text(0), "x"))
+		assert.Assert(t, strings.Contains(x.Col("Error").Text(1), "y"))
+	}
+
+	source = csv.Source(iokit.StringIO("Id,Rate\n1,1.5\n2,x\n3,3.5\n4,4.5\n5,y\n"), csv.Int("Id"), csv.Float32("Rate"))
+	dead := iokit.StringIO("")
+	q, err := source.OnError(tables.ErrorPolicy{DeadLetter: csv.Sink(dead)}).First(2).Collect()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Col("Id").Ints(), []int{1, 3})
+	x := deadLetter(dead)
+	assert.DeepEqual(t, x.Col("Index").Ints(), []int{1})
+	assert.Assert(t, strings.Contains(x.Col("Row").Text(0), "x"))
+
+	dead = iokit.StringIO("")
+	_, err = source.OnError(tables.ErrorPolicy{DeadLetter: csv.Sink(dead), MaxErrors: 1}).Collect()
+	assert.ErrorContains(t, err, "more than 1 bad rows")
+	assert.Equal(t, dead.String(), "")
+}
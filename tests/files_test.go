@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/base/tables/files"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeShards(t *testing.T, shards ...string) string {
+	dir := filepath.Join(os.TempDir(), "go-tables-test-files")
+	os.RemoveAll(dir)
+	assert.NilError(t, os.MkdirAll(dir, 0755))
+	for i, s := range shards {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("part-%02d.csv", i)), []byte(s), 0644))
+	}
+	return dir
+}
+
+func Test_Files1(t *testing.T) {
+	shards := []string{"Id,Name\n1,a\n2,b\n"}
+	for i := 1; i < 10; i++ {
+		s := "Name,Id\n"
+		for j := 0; j < 50; j++ {
+			s += fmt.Sprintf("x,%d\n", i*100+j)
+		}
+		shards = append(shards, s)
+	}
+	dir := writeShards(t, shards...)
+	defer os.RemoveAll(dir)
+
+	for _, p := range []int{1, 3} {
+		q, err := files.Read(csv.Source, filepath.Join(dir, "part-*.csv"),
+			csv.Int("Id"), csv.String("Name"),
+			files.Provenance("File"),
+			files.Parallel(p))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, q.Names(), []string{"Id", "Name", "File"})
+		assert.Equal(t, q.Len(), 2+9*50)
+		ids := q.Col("Id").Ints()
+		assert.DeepEqual(t, ids[:4], []int{1, 2, 100, 101})
+		assert.Equal(t, ids[len(ids)-1], 949)
+		assert.Equal(t, q.Col("File").Text(0), filepath.Join(dir, "part-00.csv"))
+		assert.Equal(t, q.Col("File").Text(q.Len()-1), filepath.Join(dir, "part-09.csv"))
+	}
+
+	q, err := files.Read(csv.Source, []iokit.Input{iokit.StringIO("Id\n1\n"), iokit.StringIO("Id\n2\n")}, csv.Int("Id"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Col("Id").Ints(), []int{1, 2})
+
+	_, err = files.Read(csv.Source, filepath.Join(dir, "nothing-*.csv"))
+	assert.ErrorContains(t, err, "no files")
+}
+
+func Test_Files2(t *testing.T) {
+	dir := writeShards(t, "Id,Name\n1,a\n", "Id,Rate\n2,1.5\n")
+	defer os.RemoveAll(dir)
+	_, err := files.Read(csv.Source, filepath.Join(dir, "*.csv"))
+	assert.ErrorContains(t, err, "part-01.csv")
+	assert.Assert(t, strings.Contains(err.Error(), "do not match"))
+
+	dir = writeShards(t, "Id,Name\n1,a\n", "Id,Name\n2,b\nx,c\n", "Id,Name\n3,c\n")
+	_, err = files.Read(csv.Source, filepath.Join(dir, "*.csv"), csv.Int("Id"), csv.String("Name"), files.Parallel(2))
+	assert.ErrorContains(t, err, "part-01.csv")
+}
+
+func Test_PartitionedSink1(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "go-tables-test-partitioned")
+	os.RemoveAll(root)
+	defer os.RemoveAll(root)
+
+	q := tables.New([]struct {
+		Date string
+		Id   int
+		Rate float32
+	}{
+		{"2026-10-18", 1, 0.5},
+		{"2026-10-19", 2, 1.5},
+		{"2026-10-18", 3, 2.5},
+		{"2026-10-18", 4, 3.5},
+		{"a/b", 5, 4.5},
+	})
+	err := files.Write(q, csv.Sink, root, []string{"Date"}, files.MaxRows(2), files.MaxOpen(1), csv.Int("Id"), csv.Float32("Rate"))
+	assert.NilError(t, err)
+
+	ls, err := filepath.Glob(filepath.Join(root, "*", "*"))
+	assert.NilError(t, err)
+	for i := range ls {
+		ls[i], _ = filepath.Rel(root, ls[i])
+	}
+	assert.DeepEqual(t, ls, []string{
+		"Date=2026-10-18/part-0.csv",
+		"Date=2026-10-18/part-1.csv",
+		"Date=2026-10-19/part-0.csv",
+		"Date=a%2Fb/part-0.csv",
+	})
+	x, err := files.Read(csv.Source, filepath.Join(root, "Date=2026-10-18", "*.csv"), csv.Int("Id"), csv.Float32("Rate"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"Id", "Rate"})
+	assert.DeepEqual(t, x.Col("Id").Ints(), []int{1, 3, 4})
+
+	root2 := root + "-failed"
+	defer os.RemoveAll(root2)
+	failing := tables.Lazy(func() lazy.Stream {
+		z := q.Lazy()()
+		return func(index uint64) (reflect.Value, error) {
+			if index == 3 {
+				return reflect.ValueOf(false), fmt.Errorf("failed")
+			}
+			return z(index)
+		}
+	})
+	err = failing.Drain(files.Sink(csv.Sink, root2, []string{"Date"}))
+	assert.ErrorContains(t, err, "failed")
+	ls, err = filepath.Glob(filepath.Join(root2, "*"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(ls), 0)
+}
+
+func Test_PartitionedSink2(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "go-tables-test-partitioned2")
+	os.RemoveAll(root)
+	defer os.RemoveAll(root)
+
+	// sink which is not drained does not create anything
+	_ = files.Sink(csv.Sink, root, []string{"Date"})
+	_, err := os.Stat(root)
+	assert.Assert(t, os.IsNotExist(err))
+
+	zone := time.FixedZone("UTC+3", 3*3600)
+	q := tables.New([]struct {
+		Date time.Time
+		Id   int
+	}{
+		{time.Date(2026, 10, 18, 0, 0, 0, 0, zone), 1},
+		{time.Date(2026, 10, 18, 0, 0, 0, 0, zone), 2},
+	})
+	assert.NilError(t, files.Write(q, csv.Sink, root, []string{"Date"}, csv.Int("Id")))
+	assert.NilError(t, files.Write(q.Slice(1), csv.Sink, root, []string{"Date"}, csv.Int("Id")))
+
+	ls, err := filepath.Glob(filepath.Join(root, "*", "*"))
+	assert.NilError(t, err)
+	for i := range ls {
+		ls[i], _ = filepath.Rel(root, ls[i])
+	}
+	assert.DeepEqual(t, ls, []string{
+		"Date=2026-10-18/part-0.csv",
+		"Date=2026-10-18/part-1.csv",
+	})
+	x, err := files.Read(csv.Source, filepath.Join(root, "Date=2026-10-18", "*.csv"), csv.Int("Id"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Col("Id").Ints(), []int{1, 2, 2})
+
+	// conflicting file fails sink before any file is moved
+	root3 := root + "-conflict"
+	defer os.RemoveAll(root3)
+	conflict := tables.Lazy(func() lazy.Stream {
+		z := tables.New([]struct {
+			Date string
+			Id   int
+		}{{"x", 1}, {"y", 2}}).Lazy()()
+		return func(index uint64) (v reflect.Value, err error) {
+			if v, err = z(index); err == nil && v.Kind() == reflect.Bool && !v.Bool() {
+				assert.NilError(t, os.MkdirAll(filepath.Join(root3, "Date=y"), 0755))
+				assert.NilError(t, ioutil.WriteFile(filepath.Join(root3, "Date=y", "part-0.csv"), []byte("Id\n3\n"), 0644))
+			}
+			return
+		}
+	})
+	err = conflict.Drain(files.Sink(csv.Sink, root3, []string{"Date"}, csv.Int("Id")))
+	assert.ErrorContains(t, err, "already exists")
+	ls, err = filepath.Glob(filepath.Join(root3, "*", "*"))
+	assert.NilError(t, err)
+	for i := range ls {
+		ls[i], _ = filepath.Rel(root3, ls[i])
+	}
+	assert.DeepEqual(t, ls, []string{"Date=y/part-0.csv"})
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/images"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func idxBytes(tp byte, dims []uint32, values interface{}) []byte {
+	bf := bytes.Buffer{}
+	bf.Write([]byte{0, 0, tp, byte(len(dims))})
+	_ = binary.Write(&bf, binary.BigEndian, dims)
+	_ = binary.Write(&bf, binary.BigEndian, values)
+	return bf.Bytes()
+}
+
+func Test_Idx1(t *testing.T) {
+	imgs := idxBytes(0x08, []uint32{3, 2, 2}, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+	lbls := idxBytes(0x08, []uint32{3}, []byte{7, 2, 1})
+	q, err := images.ReadIdx(iokit.Reader(bytes.NewReader(imgs), nil), iokit.Reader(bytes.NewReader(lbls), nil))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Names(), []string{"Image", "Label"})
+	assert.DeepEqual(t, q.Col("Label").Ints(), []int{7, 2, 1})
+	x := q.Col("Image").Index(1).Interface().(fu.Tensor)
+	assert.Equal(t, x.Type(), fu.Byte)
+	assert.DeepEqual(t, x.Values(), []byte{4, 5, 6, 7})
+
+	q, err = images.ReadIdx(iokit.Reader(bytes.NewReader(imgs), nil), nil, images.Float32(true), images.Image("Digit"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Names(), []string{"Digit"})
+	assert.DeepEqual(t, q.Col("Digit").Index(2).Interface().(fu.Tensor).Values(), []float32{8. / 255, 9. / 255, 10. / 255, 11. / 255})
+
+	fimgs := idxBytes(0x0d, []uint32{1, 1, 2, 2}, []float32{0.5, 1, 2, 3})
+	q, err = images.ReadIdx(iokit.Reader(bytes.NewReader(fimgs), nil), nil, images.Channels(1))
+	assert.NilError(t, err)
+	x = q.Col("Image").Index(0).Interface().(fu.Tensor)
+	assert.Equal(t, x.Type(), fu.Float32)
+	assert.Equal(t, x.Width(), 2)
+	c, _, _ := x.Dimension()
+	assert.Equal(t, c, 1)
+
+	_, err = images.ReadIdx(iokit.Reader(bytes.NewReader(imgs[:len(imgs)-1]), nil), nil)
+	assert.ErrorContains(t, err, "truncated")
+	_, err = images.ReadIdx(iokit.Reader(bytes.NewReader(imgs), nil), iokit.Reader(bytes.NewReader(lbls[:len(lbls)-1]), nil))
+	assert.ErrorContains(t, err, "truncated")
+}
+
+func writePng(t *testing.T, path string, img image.Image) {
+	assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	f, err := os.Create(path)
+	assert.NilError(t, err)
+	defer f.Close()
+	assert.NilError(t, png.Encode(f, img))
+}
+
+func Test_ImageFolder1(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "go-tables-test-images")
+	os.RemoveAll(root)
+	defer os.RemoveAll(root)
+
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range gray.Pix {
+		gray.Pix[i] = byte(i * 10)
+	}
+	writePng(t, filepath.Join(root, "dog", "1.png"), gray)
+	rgb := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < 16; i++ {
+		rgb.Set(i%4, i/4, color.NRGBA{200, 100, 50, 255})
+	}
+	writePng(t, filepath.Join(root, "cat", "1.png"), rgb)
+	writePng(t, filepath.Join(root, "cat", "2.png"), gray)
+
+	labels := tables.Enumset{}
+	q, err := images.ReadFolder(root, labels)
+	assert.NilError(t, err)
+	assert.Equal(t, q.Len(), 3)
+	assert.DeepEqual(t, labels, tables.Enumset{"cat": 0, "dog": 1})
+	assert.DeepEqual(t, q.Col("Label").Ints(), []int{0, 0, 1})
+	x := q.Col("Image").Index(0).Interface().(fu.Tensor)
+	c, _, _ := x.Dimension()
+	assert.Equal(t, c, 3)
+	assert.DeepEqual(t, x.Values().([]byte)[:1], []byte{200})
+	assert.DeepEqual(t, x.Values().([]byte)[16:17], []byte{100})
+	x = q.Col("Image").Index(1).Interface().(fu.Tensor)
+	c, _, _ = x.Dimension()
+	assert.Equal(t, c, 1)
+	assert.DeepEqual(t, x.Values(), gray.Pix)
+
+	q, err = images.ReadFolder(root, labels, images.Resize{Width: 2, Height: 2}, images.Channels(3), images.HWC, images.Float32(true))
+	assert.NilError(t, err)
+	x = q.Col("Image").Index(0).Interface().(fu.Tensor)
+	assert.Equal(t, x.Volume(), 12)
+	c, h, w := x.Dimension()
+	assert.DeepEqual(t, []int{c, h, w}, []int{3, 2, 2})
+	assert.DeepEqual(t, x.Values().([]float32)[:3], []float32{200. / 255, 100. / 255, 50. / 255})
+	x = q.Col("Image").Index(2).Interface().(fu.Tensor)
+	v := x.Values().([]float32)
+	assert.Equal(t, v[0], v[1])
+	assert.Equal(t, v[0], float32(25)/255)
+
+	_, err = images.ReadFolder(root, tables.Enumset{"cat": 0})
+	assert.ErrorContains(t, err, "dog")
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"bytes"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/libsvm"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"testing"
+)
+
+const libsvmContent = `# ranking data
+3 qid:1 1:0.5 3:1.25
+1 qid:1 2:-1 # comment
+
+0 qid:2 1:1 2:2 4:4
+`
+
+func Test_Libsvm1(t *testing.T) {
+	x, err := libsvm.Read(iokit.StringIO(libsvmContent))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"Label", "Features", "Qid"})
+	assert.Assert(t, x.Len() == 3)
+	assert.DeepEqual(t, x.Col("Label").Inspect(), []float32{3, 1, 0})
+	assert.DeepEqual(t, x.Col("Qid").Ints(), []int{1, 1, 2})
+	s := x.Col("Features").Index(0).Interface().(fu.SparseTensor)
+	assert.DeepEqual(t, s.Indices, []int{0, 2})
+	assert.DeepEqual(t, s.Values, []float32{0.5, 1.25})
+	assert.DeepEqual(t, s.Floats32(), []float32{0.5, 0, 1.25})
+
+	x, err = libsvm.Read(iokit.StringIO(libsvmContent), libsvm.Dense(4), libsvm.Features("X"))
+	assert.NilError(t, err)
+	d := x.Col("X").Index(2).Interface().(fu.Tensor)
+	assert.Equal(t, d.Width(), 4)
+	assert.DeepEqual(t, d.Floats32(), []float32{1, 2, 0, 4})
+
+	_, err = libsvm.Read(iokit.StringIO(libsvmContent), libsvm.Dense(3))
+	assert.ErrorContains(t, err, "out of width")
+	_, err = libsvm.Read(iokit.StringIO("1 3:1 2:1\n"))
+	assert.ErrorContains(t, err, "ascending")
+
+	bf := bytes.Buffer{}
+	err = libsvm.Write(x, iokit.Writer(&bf), libsvm.Features("X"))
+	assert.NilError(t, err)
+	assert.Equal(t, bf.String(), "3 qid:1 1:0.5 3:1.25\n1 qid:1 2:-1\n0 qid:2 1:1 2:2 4:4\n")
+
+	bf.Reset()
+	x, err = libsvm.Read(iokit.StringIO("1 0:1 5:2\n-1\n"), libsvm.ZeroBased(true))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"Label", "Features"})
+	err = libsvm.Write(x, iokit.Writer(&bf))
+	assert.NilError(t, err)
+	assert.Equal(t, bf.String(), "1 1:1 6:2\n-1\n")
+}
+
+func Test_Libsvm2(t *testing.T) {
+	// query id column is decided by the first parsed line, not by the first bad one
+	x, err := libsvm.Source(iokit.StringIO("x qid:1 1:1\n3 qid:1 1:0.5\n1 qid:2 2:1\n")).
+		OnError(tables.ErrorPolicy{Skip: true}).
+		Collect()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"Label", "Features", "Qid"})
+	assert.DeepEqual(t, x.Col("Qid").Ints(), []int{1, 2})
+
+	// query id column exists if option is specified
+	x, err = libsvm.Read(iokit.StringIO("3 1:0.5\n1 qid:2 2:1\n"), libsvm.Qid("Group"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"Label", "Features", "Group"})
+	assert.Assert(t, x.Col("Group").Na(0))
+	assert.Equal(t, x.Col("Group").Index(1).Int(), 2)
+}
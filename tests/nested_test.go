@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"gotest.tools/assert"
+	"testing"
+)
+
+type Address struct {
+	City   string
+	Street string `table:"street"`
+}
+
+type Audit struct {
+	Version int
+}
+
+type Person struct {
+	Name    string
+	Address Address
+	Audit
+	Vector []float32
+}
+
+func Test_Nested1(t *testing.T) {
+	q := tables.New([]Person{
+		{"Ivanov", Address{"Moscow", "Tverskaya"}, Audit{1}, []float32{1, 2, 3}},
+		{"Petrov", Address{"Kazan", "Baumana"}, Audit{2}, nil}})
+	assert.DeepEqual(t, q.Names(), []string{"Name", "Address.City", "Address.street", "Version", "Vector"})
+	assert.DeepEqual(t, q.Col("Address.City").Strings(), []string{"Moscow", "Kazan"})
+	assert.Equal(t, q.Col("Vector").Type(), fu.TensorType)
+	assert.Assert(t, !q.Col("Vector").Na(0) && q.Col("Vector").Na(1))
+
+	p := Person{}
+	q.Fetch(0, &p)
+	assert.DeepEqual(t, p, Person{"Ivanov", Address{"Moscow", "Tverskaya"}, Audit{1}, []float32{1, 2, 3}})
+	q.Fetch(1, &p)
+	assert.Equal(t, p.Address.Street, "Baumana")
+	assert.Assert(t, p.Vector == nil)
+}
+
+func Test_Nested2(t *testing.T) {
+	type Result struct {
+		Name    string
+		Address struct{ Country string }
+		Scaled  []float64
+	}
+	q := tables.New([]Person{
+		{"Ivanov", Address{"Moscow", "Tverskaya"}, Audit{1}, []float32{1, 2}},
+		{"Petrov", Address{"Kazan", "Baumana"}, Audit{2}, []float32{3}}})
+	r := q.Lazy().Map(func(p Person) (r Result) {
+		r.Name = p.Name + "@" + p.Address.City
+		r.Address.Country = "RU"
+		for _, v := range p.Vector {
+			r.Scaled = append(r.Scaled, float64(v)*2)
+		}
+		return
+	}).LuckyCollect()
+	assert.DeepEqual(t, r.Col("Name").Strings(), []string{"Ivanov@Moscow", "Petrov@Kazan"})
+	assert.DeepEqual(t, r.Col("Address.Country").Strings(), []string{"RU", "RU"})
+	x := Result{}
+	r.Fetch(0, &x)
+	assert.DeepEqual(t, x.Scaled, []float64{2, 4})
+}
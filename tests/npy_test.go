@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"testing"
+)
+
+func npyBytes(descr, shape string, values interface{}) []byte {
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }\n", descr, shape)
+	bf := bytes.Buffer{}
+	bf.WriteString("\x93NUMPY\x01\x00")
+	_ = binary.Write(&bf, binary.LittleEndian, uint16(len(header)))
+	bf.WriteString(header)
+	_ = binary.Write(&bf, binary.LittleEndian, values)
+	return bf.Bytes()
+}
+
+func Test_Npy1(t *testing.T) {
+	q := TrTable()
+	m, err := q.MatrixWithLabel([]string{"Age"}, "Rate")
+	assert.NilError(t, err)
+
+	err = m.WriteNpy(iokit.File("/tmp/go-tables-test-f.npy"), iokit.File("/tmp/go-tables-test-l.npy"))
+	assert.NilError(t, err)
+	r, err := tables.ReadNpy(iokit.File("/tmp/go-tables-test-f.npy"), iokit.File("/tmp/go-tables-test-l.npy"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, r, m)
+
+	err = m.WriteNpz(iokit.File("/tmp/go-tables-test.npz"))
+	assert.NilError(t, err)
+	x, err := tables.ReadNpz(iokit.File("/tmp/go-tables-test.npz"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"features", "labels"})
+	assert.Equal(t, x.Col("features").Type(), fu.TensorType)
+	assert.DeepEqual(t, x.Col("labels").Inspect(), m.Labels)
+	assert.DeepEqual(t, x.Col("features").Index(1).Reals(), m.Features[1:2])
+}
+
+func Test_Npz2(t *testing.T) {
+	bf := bytes.Buffer{}
+	zw := zip.NewWriter(&bf)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"id.npy", npyBytes("<i8", "(2,)", []int64{10, 20})},
+		{"images.npy", npyBytes("|u1", "(2, 2, 3)", []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})},
+		{"flag.npy", npyBytes("|b1", "(2,)", []bool{true, false})},
+	} {
+		w, err := zw.Create(f.name)
+		assert.NilError(t, err)
+		_, err = w.Write(f.data)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zw.Close())
+
+	x, err := tables.ReadNpz(iokit.Reader(bytes.NewReader(bf.Bytes()), nil))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"id", "images", "flag"})
+	assert.DeepEqual(t, x.Col("id").Ints(), []int{10, 20})
+	assert.DeepEqual(t, x.Col("flag").Inspect(), []bool{true, false})
+	img := x.Col("images").Index(1).Interface().(fu.Tensor)
+	assert.Equal(t, img.Height(), 2)
+	assert.Equal(t, img.Width(), 3)
+	assert.DeepEqual(t, img.Values(), []byte{7, 8, 9, 10, 11, 12})
+
+	bf2 := bytes.Buffer{}
+	zw = zip.NewWriter(&bf2)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"a.npy", npyBytes("<f4", "(2,)", []float32{1, 2})},
+		{"b.npy", npyBytes("<f4", "(3,)", []float32{1, 2, 3})},
+	} {
+		w, _ := zw.Create(f.name)
+		_, _ = w.Write(f.data)
+	}
+	assert.NilError(t, zw.Close())
+	_, err = tables.ReadNpz(iokit.Reader(bytes.NewReader(bf2.Bytes()), nil))
+	assert.ErrorContains(t, err, "length")
+}
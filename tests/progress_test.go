@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"strings"
+	"testing"
+)
+
+func Test_Progress1(t *testing.T) {
+	reports := []tables.ProgressInfo{}
+	report := func(p tables.ProgressInfo) { reports = append(reports, p) }
+	n, err := TrTable().Lazy().
+		Filter(func(r TR) bool { return r.Age > 30 }).
+		Progress(2, report, tables.Stage("adults")).
+		Count()
+	assert.NilError(t, err)
+	last := reports[len(reports)-1]
+	assert.Assert(t, last.Done)
+	assert.Equal(t, last.Stage, "adults")
+	assert.Equal(t, last.Rows, n)
+	assert.Equal(t, last.Rows+last.Filtered, TrTable().Len())
+	assert.Equal(t, len(reports), TrTable().Len()/2+1)
+	assert.Assert(t, strings.HasPrefix(last.String(), "adults: "))
+}
+
+func Test_Progress2(t *testing.T) {
+	m := tables.Metered(iokit.StringIO(badCSV))
+	var last tables.ProgressInfo
+	q, err := csv.Source(m, csv.Int("Id"), csv.String("Rate")).
+		Parallel().
+		Progress(1, func(p tables.ProgressInfo) { last = p }, m).
+		Collect()
+	assert.NilError(t, err)
+	assert.Equal(t, q.Len(), 5)
+	assert.Assert(t, last.Done)
+	assert.Equal(t, last.Rows, 5)
+	assert.Equal(t, last.Bytes, int64(len(badCSV)))
+}
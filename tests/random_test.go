@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/tablestest"
+	"go4ml.xyz/zorros"
+	"gotest.tools/assert"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var randomSchema = tables.Schema{Fields: []tables.Field{
+	{Name: "Id", Type: fu.Int},
+	{Name: "Rate", Type: fu.Float32, Nullable: true},
+	{Name: "Name", Type: fu.String, Nullable: true},
+	{Name: "Kind", Type: reflect.TypeOf(tables.Enum{}), Enum: tables.Enumset{"a": 0, "b": 1}},
+	{Name: "Level", Type: fu.Fixed8Type, Nullable: true},
+	{Name: "Vector", Type: fu.TensorType, Dims: []int{1, 2, 3}, Nullable: true}}}
+
+func Test_Random1(t *testing.T) {
+	q := tablestest.Random(randomSchema, tablestest.Rows(50), tablestest.Seed(42), tablestest.NaRate(0.5))
+	assert.Equal(t, q.Len(), 50)
+	assert.DeepEqual(t, q.Names(), randomSchema.Names())
+	assert.Assert(t, tables.Equal(q, tablestest.Random(randomSchema, tablestest.Rows(50), tablestest.Seed(42), tablestest.NaRate(0.5))))
+	assert.Assert(t, !tables.Equal(q, tablestest.Random(randomSchema, tablestest.Rows(50), tablestest.Seed(43), tablestest.NaRate(0.5))))
+	tablestest.AssertEqual(t, q, tablestest.RandomLazy(randomSchema, tablestest.Rows(50), tablestest.Seed(42), tablestest.NaRate(0.5)).LuckyCollect())
+
+	assert.Equal(t, q.Col("Id").Na(0), false)
+	assert.Assert(t, naCount(q.Col("Rate")) > 0)
+	for i := 0; i < q.Len(); i++ {
+		v := q.Col("Kind").Interface(i).(tables.Enum)
+		assert.Assert(t, v.Text == "a" || v.Text == "b")
+	}
+	assert.Assert(t, q.Col("Name").Unique().Len() <= tablestest.DefaultCardinality+1)
+	assert.NilError(t, q.Lazy().Validate(randomSchema).Drain(func(reflect.Value) error { return nil }))
+	assert.Equal(t, tablestest.Random(randomSchema, tablestest.Rows(0)).Len(), 0)
+}
+
+func Test_Random2(t *testing.T) {
+	for seed := 1; seed < 5; seed++ {
+		schema := tablestest.RandomSchema(8, seed)
+		tablestest.Check(t, schema, func(q *tables.Table) error {
+			if !tables.Equal(q.Concat(q.Slice(0, 0)), q) {
+				return zorros.Errorf("Concat with empty table changes table")
+			}
+			if r := q.Concat(q); r.Len() != 2*q.Len() || !tables.Equal(r.Slice(q.Len(), r.Len()), q) {
+				return zorros.Errorf("Concat of table with itself is wrong")
+			}
+			// stream without rows does not have columns
+			if q.Len() > 0 && !tables.Equal(q.Lazy().Batch(7).Flat().LuckyCollect(), q) {
+				return zorros.Errorf("Batch/Flat round-trip changes table")
+			}
+			r := q.DropNa()
+			for _, n := range r.Names() {
+				if naCount(r.Col(n)) > 0 {
+					return zorros.Errorf("DropNa keeps NA in column %v", n)
+				}
+			}
+			return nil
+		}, tablestest.Tries(20), tablestest.Rows(30), tablestest.Seed(seed*100))
+	}
+}
+
+func Test_Random3(t *testing.T) {
+	schema := tables.Schema{Fields: []tables.Field{
+		{Name: "Id", Type: fu.Int},
+		{Name: "Rate", Type: fu.Float64, Nullable: true}}}
+	tablestest.Check(t, schema, func(q *tables.Table) error {
+		r := q.FillNa(map[string]interface{}{"Rate": 0.0})
+		if naCount(r.Col("Rate")) > 0 && q.Len() > 0 {
+			return zorros.Errorf("FillNa keeps NA")
+		}
+		return nil
+	}, tablestest.NaRate(0.3))
+
+	// property fails on tables having NA, so shrunk table is the single row with NA
+	ft := &fakeT{}
+	tablestest.Check(ft, schema, func(q *tables.Table) error {
+		if naCount(q.Col("Rate")) > 0 {
+			return zorros.Errorf("has NA")
+		}
+		return nil
+	}, tablestest.NaRate(0.3))
+	assert.Assert(t, strings.Contains(ft.failed, "has NA"))
+
+	q := tablestest.Random(schema, tablestest.Rows(100), tablestest.NaRate(0.3), tablestest.Seed(1))
+	r := tablestest.Shrink(q, func(x *tables.Table) bool {
+		c, ok := x.ColIfExists("Rate")
+		return ok && naCount(c) > 0
+	})
+	assert.Equal(t, r.Len(), 1)
+	assert.DeepEqual(t, r.Names(), []string{"Rate"})
+	assert.Assert(t, r.Col("Rate").Na(0))
+}
+
+func naCount(c *tables.Column) int {
+	_, na := c.Raw()
+	return na.Count()
+}
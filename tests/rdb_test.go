@@ -2,11 +2,17 @@ package tests
 
 import (
 	"database/sql"
+	"fmt"
 	_ "github.com/mattn/go-sqlite3"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
 	"go4ml.xyz/base/tables/rdb"
 	"gotest.tools/assert"
 	"os"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
 )
 
 func init() {
@@ -82,3 +88,260 @@ func Test_SQL3(t *testing.T) {
 		assert.Assert(t, y.Col("aa").Index(i).Int() == q.Col("Age").Index(i).Int())
 	}
 }
+
+func Test_SQL4(t *testing.T) {
+	var err error
+	q := TrTable()
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+
+	err = rdb.Write(url, q.Slice(0, 3), rdb.Table("q4"), rdb.DropIfExists)
+	assert.NilError(t, err)
+	err = rdb.Write(url, q.Slice(3, q.Len()), rdb.Table("q4"), rdb.AppendIfExists)
+	assert.NilError(t, err)
+
+	x, err := rdb.Read(url, rdb.Query("select * from q4"))
+	assert.NilError(t, err)
+	assertTrData(t, x)
+
+	err = rdb.Write(url, tables.New([]struct{ Name, Age string }{{"Ivanov", "old"}}),
+		rdb.Table("q4"), rdb.AppendIfExists)
+	assert.ErrorContains(t, err, "not compatible")
+
+	err = rdb.Write(url, tables.New([]struct{ Nick string }{{"Ivanov"}}),
+		rdb.Table("q4"), rdb.AppendIfExists)
+	assert.ErrorContains(t, err, "does not have column")
+}
+
+func Test_SQL5(t *testing.T) {
+	var err error
+	q := TrTable()
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+
+	err = rdb.Write(url, q.Slice(0, 4), rdb.Table("q5"), rdb.DropIfExists,
+		rdb.VARCHAR("Name", 256).PrimaryKey())
+	assert.NilError(t, err)
+
+	u := tables.New([]TR{{"Petrov", 45, 1.6}, {"Kozlov", 42, 1.3}})
+	err = rdb.Write(url, u, rdb.Table("q5"), rdb.InsertUpdateIfExists,
+		rdb.Batch(2),
+		rdb.VARCHAR("Name", 256).PrimaryKey())
+	assert.NilError(t, err)
+
+	x, err := rdb.Read(url, rdb.Query("select * from q5 order by Name"))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 5)
+	assert.DeepEqual(t, x.Col("Name").Strings(), []string{"Gavrilov", "Ivanov", "Kozlov", "Petrov", "Sidorov"})
+	assert.DeepEqual(t, x.Col("Age").Ints(), []int{20, 32, 42, 45, 55})
+
+	err = rdb.Write(url, u, rdb.Table("q5"), rdb.InsertUpdateIfExists)
+	assert.ErrorContains(t, err, "primary key")
+}
+
+func Test_SQL6(t *testing.T) {
+	var err error
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+
+	err = rdb.Write(url, TrTable(), rdb.Table("q6"), rdb.DropIfExists,
+		rdb.CommitEvery(2), rdb.Batch(3))
+	assert.NilError(t, err)
+	x, err := rdb.Read(url, rdb.Table("q6"))
+	assert.NilError(t, err)
+	assertTrData(t, x)
+
+	// rows committed before failure stay in the table
+	err = TrTable().Lazy().Transform(func(lr fu.Struct) (fu.Struct, bool, error) {
+		if lr.Text("Name") == "Gavrilov" {
+			return lr, false, fmt.Errorf("failed")
+		}
+		return lr, true, nil
+	}).Drain(rdb.Sink(url, rdb.Table("q7"), rdb.DropIfExists, rdb.CommitEvery(2)))
+	assert.ErrorContains(t, err, "failed")
+	x, err = rdb.Read(url, rdb.Table("q7"))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 2)
+}
+
+func Test_SQLDialect1(t *testing.T) {
+	assert.Equal(t, rdb.DialectOf("sqlite3"), rdb.Sqlite)
+	assert.Equal(t, rdb.DialectOf("unknown"), rdb.Generic)
+	assert.Equal(t, rdb.Postgres.Table("data", `q"1`), `"data"."q""1"`)
+	assert.Equal(t, rdb.Mysql.Table("", "q`1"), "`q``1`")
+	assert.Equal(t, rdb.Postgres.Placeholder(3), "$3")
+	assert.Equal(t, rdb.Mysql.Placeholder(3), "?")
+	assert.Equal(t, rdb.Postgres.TypeOf(fu.TensorType), "BYTEA")
+	assert.Equal(t, rdb.Sqlite.TypeOf(fu.Ts), "DATETIME")
+	assert.Equal(t, rdb.Postgres.TypeOf(fu.Ts), "TIMESTAMP")
+
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+	q := tables.New([]struct {
+		Name   string
+		Weight fu.Fixed8
+		Image  fu.Tensor
+	}{
+		{"first", fu.AsFixed8(0.5), fu.MakeByteTensor(1, 2, 2, []byte{1, 2, 3, 4})},
+		{"second", fu.AsFixed8(-0.25), fu.MakeFloat32Tensor(1, 1, 2, []float32{1.5, 2.5})},
+	})
+	err := rdb.Write(url, q, rdb.Table(`select "q"`), rdb.DropIfExists)
+	assert.NilError(t, err)
+
+	x, err := rdb.Read(url, rdb.Query(`select Name, Weight from "select ""q"""`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Col("Name").Strings(), []string{"first", "second"})
+	assert.DeepEqual(t, x.Col("Weight").Floats(), []float64{0.5, -0.25})
+}
+
+func Test_SQLIntrospect1(t *testing.T) {
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+	db, err := sql.Open("sqlite3", "file:/tmp/go-tables-test.sqlite3")
+	assert.NilError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`drop table if exists typed`)
+	assert.NilError(t, err)
+	_, err = db.Exec(`create table typed (
+		Id INTEGER PRIMARY KEY,
+		Day DATE NOT NULL,
+		Data BLOB,
+		Price DECIMAL(10,2),
+		Count INT UNSIGNED,
+		Level TINYINT)`)
+	assert.NilError(t, err)
+	_, err = db.Exec(`insert into typed values
+		(1, '2026-10-18', x'0102', 10.5, 3000000000, -3),
+		(2, '2026-10-19', null, 0.25, 1, 7)`)
+	assert.NilError(t, err)
+
+	names, err := rdb.Tables(url)
+	assert.NilError(t, err)
+	assert.Assert(t, fu.IndexOf("typed", names) >= 0)
+
+	cols, err := rdb.Columns(url, "typed")
+	assert.NilError(t, err)
+	assert.Assert(t, len(cols) == 6)
+	assert.Equal(t, cols[0].Name, "Id")
+	assert.Assert(t, cols[0].PrimaryKey)
+	assert.Assert(t, !cols[1].PrimaryKey && !cols[1].Nullable)
+	assert.Assert(t, cols[2].Nullable)
+	assert.Equal(t, cols[3].SqlType, "DECIMAL(10,2)")
+	assert.Equal(t, cols[4].Type(), fu.Uint32)
+
+	_, err = rdb.Columns(url, "nonexistent")
+	assert.ErrorContains(t, err, "does not exist")
+
+	x, err := rdb.Read(url, rdb.Table("typed"))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 2)
+	assert.Equal(t, x.Col("Day").Type(), fu.Ts)
+	assert.Equal(t, x.Col("Data").Type(), reflect.TypeOf([]byte{}))
+	assert.Equal(t, x.Col("Price").Type(), fu.Float64)
+	assert.Equal(t, x.Col("Count").Type(), fu.Uint32)
+	assert.Equal(t, x.Col("Level").Type(), fu.Int8)
+	assert.Equal(t, x.Col("Day").Index(0).Interface().(time.Time).Format("2006-01-02"), "2026-10-18")
+	assert.DeepEqual(t, x.Col("Data").Index(0).Interface(), []byte{1, 2})
+	assert.Assert(t, x.Col("Data").Na(1))
+	assert.Equal(t, x.Col("Count").Index(0).Interface(), uint32(3000000000))
+	assert.Equal(t, x.Col("Level").Index(0).Interface(), int8(-3))
+}
+
+func Test_SQLPartition1(t *testing.T) {
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+	type R struct {
+		Id    int
+		Value float64
+	}
+	rows := make([]R, 100)
+	for i := range rows {
+		rows[i] = R{i + 1, float64(i) / 2}
+	}
+	err := rdb.Write(url, tables.New(rows), rdb.Table("parts"), rdb.DropIfExists)
+	assert.NilError(t, err)
+
+	x, err := rdb.Read(url, rdb.Table("parts"), rdb.Partition("Id", 4))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 100)
+	ids := x.Col("Id").Ints()
+	sort.Ints(ids)
+	for i, id := range ids {
+		assert.Equal(t, id, i+1)
+	}
+
+	n, err := rdb.Source(url, rdb.Table("parts"), rdb.Partition("Id", 4)).First(10).Count()
+	assert.NilError(t, err)
+	assert.Assert(t, n == 10)
+
+	_, err = rdb.Read(url, rdb.Query("select * from parts"), rdb.Partition("Id", 4))
+	assert.ErrorContains(t, err, "rdb.Table")
+}
+
+func Test_SQLIncremental1(t *testing.T) {
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+	wmfile := "/tmp/go-tables-test.watermark"
+	_ = os.Remove(wmfile)
+	type R struct{ Id int }
+	rows := make([]R, 20)
+	for i := range rows {
+		rows[i] = R{i + 1}
+	}
+	err := rdb.Write(url, tables.New(rows[:10]), rdb.Table("incr"), rdb.DropIfExists)
+	assert.NilError(t, err)
+
+	wm := rdb.WatermarkFile(wmfile)
+	x, err := rdb.Read(url, rdb.Table("incr"), rdb.Incremental("Id", wm))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 10)
+	v, err := wm.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, v, int64(10))
+
+	err = rdb.Write(url, tables.New(rows[10:]), rdb.Table("incr"), rdb.AppendIfExists)
+	assert.NilError(t, err)
+	x, err = rdb.Read(url, rdb.Table("incr"), rdb.Incremental("Id", wm), rdb.Partition("Id", 3))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 10)
+	ids := x.Col("Id").Ints()
+	sort.Ints(ids)
+	assert.Equal(t, ids[0], 11)
+
+	x, err = rdb.Read(url, rdb.Table("incr"), rdb.Incremental("Id", wm))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 0)
+	v, err = wm.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, v, int64(20))
+}
+
+func Test_SQLIncremental2(t *testing.T) {
+	url := "sqlite3:file:/tmp/go-tables-test.sqlite3"
+	wmfile := "/tmp/go-tables-test.watermark2"
+	_ = os.Remove(wmfile)
+	type R struct{ Id int }
+	err := rdb.Write(url, tables.New([]R{{1}, {2}, {3}}), rdb.Table("incr2"), rdb.DropIfExists)
+	assert.NilError(t, err)
+
+	wm := rdb.WatermarkFile(wmfile)
+	inc := rdb.Incremental("Id", wm)
+	count := 0
+	sink := func(fail bool) tables.Sink {
+		return func(v reflect.Value) error {
+			if v.Kind() != reflect.Bool {
+				count++
+			} else if v.Bool() && fail {
+				return fmt.Errorf("commit failed")
+			}
+			return nil
+		}
+	}
+	err = rdb.Source(url, rdb.Table("incr2"), inc).Drain(inc.Commit(sink(true)))
+	assert.ErrorContains(t, err, "commit failed")
+	assert.Equal(t, count, 3)
+	v, err := wm.Get()
+	assert.NilError(t, err)
+	assert.Assert(t, v == nil)
+
+	// rows are read again since they were not committed
+	err = rdb.Source(url, rdb.Table("incr2"), inc, rdb.Partition("Id", 2)).Drain(inc.Commit(sink(false)))
+	assert.NilError(t, err)
+	assert.Equal(t, count, 6)
+	v, err = wm.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, v, int64(3))
+}
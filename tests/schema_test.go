@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"encoding/json"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"reflect"
+	"testing"
+)
+
+func Test_Schema1(t *testing.T) {
+	s := TrTable().Schema()
+	assert.DeepEqual(t, s.Names(), []string{"Name", "Age", "Rate"})
+	f, ok := s.Field("Rate")
+	assert.Assert(t, ok)
+	assert.Equal(t, f.Type, fu.Float32)
+	assert.Assert(t, !f.Nullable)
+
+	ls, err := TrTable().Lazy().Schema()
+	assert.NilError(t, err)
+	assert.Assert(t, reflect.DeepEqual(ls, s))
+
+	s.Fields[0].Enum = tables.Enumset{"Ivanov": 0, "Petrov": 1}
+	s.Fields = append(s.Fields, tables.Field{Name: "Image", Type: fu.TensorType, Nullable: true, Dims: []int{1, 2, 3}})
+	bs, err := json.Marshal(s)
+	assert.NilError(t, err)
+	x := tables.Schema{}
+	assert.NilError(t, json.Unmarshal(bs, &x))
+	assert.Assert(t, reflect.DeepEqual(x, s), "%v != %v", x, s)
+	assert.Equal(t, x.String(), s.String())
+
+	bs, _ = json.Marshal(map[string]interface{}{"Fields": []interface{}{map[string]string{"name": "X", "type": "chan int"}}})
+	assert.ErrorContains(t, json.Unmarshal(bs, &x), "unknown type")
+}
+
+const schemaCSV = `Name,Age,Rate
+Ivanov,32,1.2
+Petrov,44,1.5
+Sidorov,,2.0
+Ivanov,20,x
+`
+
+func Test_Validate2(t *testing.T) {
+	s := tables.Schema{Fields: []tables.Field{
+		{Name: "Name", Type: fu.String, Enum: tables.Enumset{"Ivanov": 0, "Petrov": 1}},
+		{Name: "Rate", Type: fu.String},
+		{Name: "Age", Type: fu.Int},
+	}}
+	source := csv.Source(iokit.StringIO(schemaCSV), csv.String("Name"), csv.Int("Age"), csv.String("Rate"))
+	_, err := source.Validate(s).Collect()
+	assert.ErrorContains(t, err, "Sidorov which is not in enumeration")
+
+	summary := &tables.ErrorSummary{}
+	q, err := source.Validate(s).OnError(tables.ErrorPolicy{Skip: true}, summary).Collect()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Col("Name").Strings(), []string{"Ivanov", "Petrov", "Ivanov"})
+	assert.Equal(t, summary.Skipped, 1)
+
+	s.Fields[0].Enum = nil
+	_, err = source.Validate(s).Collect()
+	assert.ErrorContains(t, err, "column Age can't be NA")
+
+	s.Fields[2].Type = fu.Int64
+	s.Fields[2].Nullable = true
+	_, err = source.Validate(s).Collect()
+	assert.ErrorContains(t, err, "column Age has type int but int64 expected")
+}
+
+func Test_Cast3(t *testing.T) {
+	s := tables.Schema{Fields: []tables.Field{
+		{Name: "Age", Type: fu.Float64},
+		{Name: "Name", Type: reflect.TypeOf(tables.Enum{}), Enum: tables.Enumset{"Ivanov": 0, "Petrov": 1}},
+		{Name: "Comment", Type: fu.String, Nullable: true},
+	}}
+	q, err := PrepareTable(t).Cast(s)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Names(), []string{"Age", "Name", "Comment"})
+	assert.DeepEqual(t, q.Col("Age").Floats(), []float64{32, 44})
+	assert.Equal(t, q.Col("Name").Interface(1).(tables.Enum), tables.Enum{"Petrov", 1})
+	assert.Assert(t, q.Col("Comment").Na(0) && q.Col("Comment").Na(1))
+	assert.Equal(t, q.Schema().String(), "{Age float64, Name tables.Enum enum(2), Comment string null}")
+
+	s.Fields[1].Enum = tables.Enumset{"Ivanov": 0}
+	_, err = PrepareTable(t).Cast(s)
+	assert.ErrorContains(t, err, "Petrov which is not in enumeration")
+
+	_, err = PrepareTable(t).Cast(tables.Schema{Fields: []tables.Field{{Name: "Name", Type: fu.Int}}})
+	assert.ErrorContains(t, err, "column Name can't be converted to int")
+
+	_, err = PrepareTable(t).Cast(tables.Schema{Fields: []tables.Field{{Name: "Unknown", Type: fu.Int}}})
+	assert.ErrorContains(t, err, "column Unknown does not exist")
+}
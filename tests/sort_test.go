@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_Sort1(t *testing.T) {
+	q := PrepareTable(t).Sort("Name", tables.DESC)
+	assert.DeepEqual(t, q.Col("Name").Strings(), []string{"Petrov", "Ivanov"})
+	q = TrTable().Sort([]string{"Age", "Name"})
+	assert.Equal(t, q.Len(), TrTable().Len())
+	assert.Assert(t, sort.IntsAreSorted(q.Col("Age").Ints()))
+
+	// table with Enum column is sorted in memory without spilling it to disk
+	cls := tables.Enumset{}
+	e := csv.Source(iokit.StringIO("Id,Class\n3,b\n1,a\n2,b\n"), csv.Int("Id"), csv.Meta(cls.Enum(), "Class")).LuckyCollect()
+	q = e.Sort("Id")
+	assert.DeepEqual(t, q.Col("Id").Ints(), []int{1, 2, 3})
+	assert.DeepEqual(t, q.Col("Class").Strings(), []string{"a", "b", "b"})
+}
+
+func Test_SortBy2(t *testing.T) {
+	const N = 1000
+	rng := rand.New(rand.NewSource(42))
+	key := make([]int, N)
+	seq := make([]int, N)
+	rate := make([]float32, N)
+	text := make([]string, N)
+	date := make([]time.Time, N)
+	na := fu.Bits{}
+	for i := 0; i < N; i++ {
+		key[i] = rng.Intn(50)
+		seq[i] = i
+		rate[i] = rng.Float32()
+		text[i] = string(rune('a' + rng.Intn(26)))
+		date[i] = time.Date(2020, 1, 1+rng.Intn(365), 0, 0, 0, 0, time.UTC)
+		if i%17 == 0 {
+			na.Set(i, true)
+		}
+	}
+	q := tables.MakeTable(
+		[]string{"Key", "Seq", "Rate", "Text", "Date"},
+		[]reflect.Value{reflect.ValueOf(key), reflect.ValueOf(seq), reflect.ValueOf(rate), reflect.ValueOf(text), reflect.ValueOf(date)},
+		[]fu.Bits{na, {}, {}, {}, {}},
+		N)
+
+	for _, desc := range []bool{false, true} {
+		r, err := q.Lazy().SortBy("Key", tables.Desc(desc), tables.RunSize(64)).Collect()
+		assert.NilError(t, err)
+		assert.Equal(t, r.Len(), N)
+		k, s := r.Col("Key"), r.Col("Seq")
+		for i := 1; i < N; i++ {
+			if k.Na(i - 1) {
+				assert.Assert(t, k.Na(i), "NA goes last")
+				continue
+			}
+			if k.Na(i) {
+				continue
+			}
+			if desc {
+				assert.Assert(t, k.Int(i-1) >= k.Int(i))
+			} else {
+				assert.Assert(t, k.Int(i-1) <= k.Int(i))
+			}
+			if k.Int(i-1) == k.Int(i) {
+				assert.Assert(t, s.Int(i-1) < s.Int(i), "sort is stable")
+			}
+		}
+		for i := 0; i < N; i++ {
+			j := r.Col("Seq").Int(i)
+			assert.Equal(t, r.Col("Key").Na(i), na.Bit(j))
+			assert.Equal(t, r.Col("Rate").Real(i), rate[j])
+			assert.Equal(t, r.Col("Text").Text(i), text[j])
+			assert.Assert(t, r.Col("Date").Interface(i).(time.Time).Equal(date[j]))
+		}
+	}
+
+	r, err := q.Lazy().SortBy([]string{"Text", "Rate"}, tables.RunSize(100)).First(10).Collect()
+	assert.NilError(t, err)
+	assert.Equal(t, r.Len(), 10)
+	assert.Equal(t, r.Col("Text").Text(0), "a")
+
+	_, err = q.Lazy().SortBy("Unknown").Collect()
+	assert.ErrorContains(t, err, "does not exist")
+}
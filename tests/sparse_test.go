@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"gotest.tools/assert"
+	"reflect"
+	"testing"
+)
+
+func sparseTable() *tables.Table {
+	return tables.New([]struct {
+		Rate   float32
+		Color  tables.Enum
+		Flag   bool
+		Extra  fu.SparseTensor
+		Target int
+	}{
+		{1.5, tables.Enum{"red", 0}, true, fu.MakeSparseTensor(4, []int{3}, []float32{2}), 1},
+		{0, tables.Enum{"blue", 2}, false, fu.MakeSparseTensor(4, nil, nil), 0},
+		{-1, tables.Enum{"green", 1}, false, fu.MakeSparseTensor(4, []int{0, 1}, []float32{1, 3}), 1},
+	})
+}
+
+func Test_SparseMatrix1(t *testing.T) {
+	q := sparseTable()
+	m, err := q.SparseMatrix([]string{"Rate", "Color", "Flag", "Extra"}, "Target")
+	assert.NilError(t, err)
+	assert.Equal(t, m.Width, 1+3+1+4)
+	assert.Equal(t, m.Length, 3)
+	assert.DeepEqual(t, m.Indptr, []int{0, 4, 5, 9})
+	assert.DeepEqual(t, m.Indices, []int{0, 1, 4, 8, 3, 0, 2, 5, 6})
+	assert.DeepEqual(t, m.Values, []float32{1.5, 1, 1, 2, 1, -1, 1, 1, 3})
+	assert.DeepEqual(t, m.Labels, []float32{1, 0, 1})
+
+	d := m.Dense()
+	assert.DeepEqual(t, d.Features[9:18], []float32{0, 0, 0, 1, 0, 0, 0, 0, 0})
+	assert.DeepEqual(t, d.Sparse(), m)
+
+	dm, err := q.MatrixWithLabel([]string{"Rate", "Flag", "Extra"}, "Target")
+	assert.NilError(t, err)
+	sm, err := q.SparseMatrix([]string{"Rate", "Flag", "Extra"}, "Target")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sm.Dense(), dm)
+
+	s := m.Slice(1, 3)
+	assert.Equal(t, s.Length, 2)
+	assert.DeepEqual(t, s.Indptr, []int{0, 1, 5})
+	assert.DeepEqual(t, s.Labels, []float32{0, 1})
+	assert.DeepEqual(t, s.Row(1).Floats32(), d.Features[18:27])
+	assert.DeepEqual(t, m.AsColumn().Index(2).Reals(), d.Features[18:27])
+
+	_, err = q.SparseMatrix([]string{"Color", "Target"}, "")
+	assert.NilError(t, err)
+	_, err = tables.New([]struct{ Name string }{{"a"}}).SparseMatrix([]string{"Name"}, "")
+	assert.ErrorContains(t, err, "unsupported")
+}
+
+func Test_SparseBatch1(t *testing.T) {
+	q := sparseTable()
+	n := 0
+	err := q.Lazy().Batch(2).Reduce(func(b *tables.Table) (fu.Struct, bool, error) {
+		m, err := b.SparseMatrix([]string{"Extra"}, "")
+		if err != nil {
+			return fu.Struct{}, false, err
+		}
+		n += m.Length
+		return fu.Struct{}, false, nil
+	}).Drain(func(v reflect.Value) error { return nil })
+	assert.NilError(t, err)
+	assert.Equal(t, n, 3)
+
+	x := q.Lazy().Batch(2).Flat().LuckyCollect()
+	assert.DeepEqual(t, x.Col("Extra").Inspect(), q.Col("Extra").Inspect())
+}
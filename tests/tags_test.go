@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"database/sql"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"gotest.tools/assert"
+	"testing"
+)
+
+type Customer struct {
+	Id      int    `table:"customer_id"`
+	Name    string `table:",omitempty"`
+	Age     *int
+	Rate    sql.NullFloat64
+	private int
+	Cache   []byte `table:"-"`
+}
+
+func Test_Tags1(t *testing.T) {
+	age := 32
+	q := tables.New([]Customer{
+		{Id: 1, Name: "Ivanov", Age: &age, Rate: sql.NullFloat64{Float64: 1.2, Valid: true}},
+		{Id: 2, Name: "Petrov"}})
+	assert.DeepEqual(t, q.Names(), []string{"customer_id", "Name", "Age", "Rate"})
+	assert.Equal(t, q.Col("Age").Type(), fu.Int)
+	assert.Equal(t, q.Col("Rate").Type(), fu.Float64)
+	assert.Assert(t, !q.Col("Age").Na(0) && q.Col("Age").Na(1))
+	assert.Assert(t, !q.Col("Rate").Na(0) && q.Col("Rate").Na(1))
+
+	c := Customer{}
+	q.Fetch(1, &c)
+	assert.Equal(t, c.Id, 2)
+	assert.Assert(t, c.Age == nil && !c.Rate.Valid)
+	q.Fetch(0, &c)
+	assert.Equal(t, *c.Age, 32)
+	assert.Equal(t, c.Rate, sql.NullFloat64{Float64: 1.2, Valid: true})
+
+	c = Customer{Name: "x"}
+	q.Without("Name").Fetch(0, &c)
+	assert.Equal(t, c.Name, "")
+	assert.Equal(t, c.Id, 1)
+
+	r := q.Lazy().Map(func(c Customer) Customer {
+		if c.Age == nil {
+			a := 18
+			c.Age = &a
+		}
+		c.Rate.Valid = false
+		return c
+	}).LuckyCollect()
+	assert.DeepEqual(t, r.Names(), []string{"customer_id", "Name", "Age", "Rate"})
+	assert.DeepEqual(t, r.Col("Age").Ints(), []int{32, 18})
+	assert.Assert(t, r.Col("Rate").Na(0) && r.Col("Rate").Na(1))
+
+	n := q.Lazy().Filter(func(c struct {
+		Id      int    `table:"customer_id"`
+		Comment string `table:",omitempty"`
+	}) bool {
+		return c.Id > 1 && c.Comment == ""
+	}).LuckyCount()
+	assert.Equal(t, n, 1)
+
+	assert.DeepEqual(t, fu.FieldsOf(Customer{}), []string{"customer_id", "Name", "Age", "Rate"})
+}
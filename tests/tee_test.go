@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"fmt"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/fu/lazy"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func teeTable(n int) *tables.Table {
+	type R struct {
+		Id   int
+		Name string
+	}
+	r := make([]R, n)
+	for i := range r {
+		r[i] = R{i, fmt.Sprintf("name%d", i)}
+	}
+	return tables.New(r)
+}
+
+func failingSink(sink tables.Sink, at int) tables.Sink {
+	n := 0
+	return func(v reflect.Value) error {
+		if v.Kind() != reflect.Bool {
+			if n++; n == at {
+				return fmt.Errorf("sink failed")
+			}
+		}
+		return sink(v)
+	}
+}
+
+func Test_DrainAll1(t *testing.T) {
+	q := teeTable(10)
+	expected := iokit.StringIO("")
+	assert.NilError(t, q.Lazy().Drain(csv.Sink(expected)))
+
+	a, b := iokit.StringIO(""), iokit.StringIO("")
+	assert.NilError(t, q.Lazy().DrainAll(csv.Sink(a), csv.Sink(b)))
+	assert.Equal(t, a.String(), expected.String())
+	assert.Equal(t, b.String(), expected.String())
+
+	a, b = iokit.StringIO(""), iokit.StringIO("")
+	err := q.Lazy().DrainAll(csv.Sink(a), failingSink(csv.Sink(b), 5))
+	assert.ErrorContains(t, err, "sink failed")
+	assert.Equal(t, a.String(), "")
+	assert.Equal(t, b.String(), "")
+}
+
+func drainConcurrently(zx []tables.Lazy, sinks ...tables.Sink) []error {
+	errs := make([]error, len(zx))
+	wg := sync.WaitGroup{}
+	wg.Add(len(zx))
+	for i := range zx {
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = zx[i].Drain(sinks[i])
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func Test_Tee1(t *testing.T) {
+	q := teeTable(100)
+	expected := iokit.StringIO("")
+	assert.NilError(t, q.Lazy().Drain(csv.Sink(expected)))
+
+	a, b, c := iokit.StringIO(""), iokit.StringIO(""), iokit.StringIO("")
+	zx := q.Lazy().Tee(3, 4)
+	zx[1] = zx[1].Parallel(4)
+	errs := drainConcurrently(zx, csv.Sink(a), csv.Sink(b), csv.Sink(c))
+	assert.DeepEqual(t, errs, []error{nil, nil, nil})
+	assert.Equal(t, a.String(), expected.String())
+	assert.Equal(t, b.String(), expected.String())
+	assert.Equal(t, c.String(), expected.String())
+
+	a, b = iokit.StringIO(""), iokit.StringIO("")
+	errs = drainConcurrently(q.Lazy().Tee(2, 4), csv.Sink(a), failingSink(csv.Sink(b), 50))
+	assert.ErrorContains(t, errs[0], "stopped before the end")
+	assert.ErrorContains(t, errs[1], "sink failed")
+	assert.Equal(t, a.String(), "")
+	assert.Equal(t, b.String(), "")
+
+	failing := tables.Lazy(func() lazy.Stream {
+		z := q.Lazy()()
+		return func(index uint64) (reflect.Value, error) {
+			if index == 30 {
+				return fu.False, fmt.Errorf("source failed")
+			}
+			return z(index)
+		}
+	})
+	a, b = iokit.StringIO(""), iokit.StringIO("")
+	errs = drainConcurrently(failing.Tee(2), csv.Sink(a), csv.Sink(b))
+	assert.ErrorContains(t, errs[0], "source failed")
+	assert.ErrorContains(t, errs[1], "source failed")
+	assert.Equal(t, a.String(), "")
+	assert.Equal(t, b.String(), "")
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/model"
+	"go4ml.xyz/base/model/text"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"math"
+	"testing"
+)
+
+var textCorpus = tables.New([]struct {
+	Id   int
+	Text string
+}{
+	{1, "The cat sat on the mat."},
+	{2, "The dog sat on the log!"},
+	{3, "Cats and dogs, cats and DOGS"},
+	{4, "A mat"},
+})
+
+func Test_Tokenizer1(t *testing.T) {
+	tk := text.Tokenizer{Lowercase: true, MinLength: 2, StopWords: []string{"the"}, MaxGram: 2}
+	assert.DeepEqual(t, tk.Tokenize("The cat sat, on a mat"),
+		[]string{"cat", "sat", "on", "mat", "cat sat", "sat on", "on mat"})
+	assert.DeepEqual(t, text.Tokenizer{}.Tokenize("Hello, World!"), []string{"Hello", "World"})
+	tk = text.Tokenizer{Lowercase: true, StopWords: []string{"The", "ON"}}
+	assert.DeepEqual(t, tk.Words("The cat sat on THE mat"), []string{"cat", "sat", "mat"})
+	tk = text.Tokenizer{StopWords: []string{"The"}}
+	assert.DeepEqual(t, tk.Words("The cat sat on the mat"), []string{"cat", "sat", "on", "the", "mat"})
+	assert.DeepEqual(t, text.NGrams([]string{"a", "b", "c"}, 2, 3), []string{"a b", "b c", "a b c"})
+}
+
+func Test_Vectorizer1(t *testing.T) {
+	m, err := text.Vectorizer{
+		Column:    "Text",
+		Tokenizer: text.Tokenizer{Lowercase: true},
+		MinDf:     2,
+		MaxDf:     0.5,
+	}.Fit(textCorpus)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, m.Terms, []string{"mat", "on", "sat", "the"})
+	assert.DeepEqual(t, m.Df, []int{2, 2, 2, 2})
+	assert.Equal(t, m.Documents, 4)
+
+	q := textCorpus.Lazy().BatchTransform(3, m.FeaturesMapper).LuckyCollect()
+	assert.DeepEqual(t, q.Names(), []string{"Id", "Features"})
+	assert.DeepEqual(t, q.Col("Features").Index(0).Interface().(fu.Tensor).Values(), []float32{1, 1, 1, 2})
+	assert.DeepEqual(t, q.Col("Features").Index(2).Interface().(fu.Tensor).Values(), []float32{0, 0, 0, 0})
+
+	m, err = text.Vectorizer{
+		Column:    "Text",
+		Output:    "Tfidf",
+		Tokenizer: text.Tokenizer{Lowercase: true},
+		TfIdf:     true,
+		Normalize: true,
+		Sparse:    true,
+	}.Fit(textCorpus)
+	assert.NilError(t, err)
+	j, ok := m.Index("cats")
+	assert.Assert(t, ok)
+	assert.Equal(t, m.Idf(j), float32(math.Log(5./2)+1))
+	v := m.Vectorize("cats cats and")
+	assert.Equal(t, v.Width, m.Width())
+	assert.Equal(t, len(v.Indices), 2)
+	assert.Assert(t, math.Abs(float64(v.Values[0]*v.Values[0]+v.Values[1]*v.Values[1])-1) < 1e-6)
+
+	q = textCorpus.Lazy().BatchTransform(10, m.FeaturesMapper).LuckyCollect()
+	assert.Equal(t, q.Col("Tfidf").Type(), fu.SparseTensorType)
+	sm, err := q.SparseMatrix([]string{"Tfidf"}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, sm.Width, m.Width())
+
+	f := iokit.File("/tmp/go-tables-test-vectorizer.zip")
+	assert.NilError(t, model.Memorize(f, model.MemorizeMap{"text": m}))
+	pm, err := model.Objectify(f, model.ObjectifyMap{"text": text.Objectify})
+	assert.NilError(t, err)
+	m2 := pm["text"].(*text.Model)
+	assert.DeepEqual(t, m2.Terms, m.Terms)
+	assert.DeepEqual(t, m2.Vectorize("cats cats and"), v)
+}
+
+func Test_Hashing1(t *testing.T) {
+	_, err := text.Vectorizer{Column: "Text", TfIdf: true, Hashing: 16}.Fit(nil)
+	assert.ErrorContains(t, err, "fit")
+	m, err := text.Vectorizer{Column: "Text", Tokenizer: text.Tokenizer{Lowercase: true}, Hashing: 16, Binary: true}.Fit(nil)
+	assert.NilError(t, err)
+	v := m.Vectorize("The cat sat on the mat")
+	assert.Equal(t, v.Width, 16)
+	for _, x := range v.Values {
+		assert.Equal(t, x, float32(1))
+	}
+	m, err = text.Vectorizer{Column: "Text", Tokenizer: text.Tokenizer{Lowercase: true}, Hashing: 16, TfIdf: true}.Fit(textCorpus)
+	assert.NilError(t, err)
+	j, _ := m.Index("the")
+	assert.Assert(t, m.Df[j] >= 2)
+	q := textCorpus.Lazy().BatchTransform(2, m.FeaturesMapper).LuckyCollect()
+	assert.Equal(t, q.Col("Features").Index(0).Interface().(fu.Tensor).Volume(), 16)
+}
@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"go4ml.xyz/base/tables"
+	"gotest.tools/assert"
+	"testing"
+)
+
+func Test_Union1(t *testing.T) {
+	a := tables.New([]struct {
+		Id   int
+		Rate float32
+	}{{1, 0.5}, {2, 1.5}})
+	b := tables.New([]struct {
+		Rate    float64
+		Comment string
+		Id      int32
+	}{{2.5, "third", 3}})
+	empty := tables.New([]struct{ Other bool }{}).Lazy()
+
+	q := a.ConcatAligned(b)
+	assert.DeepEqual(t, q.Names(), []string{"Id", "Rate", "Comment"})
+	assert.DeepEqual(t, q.Col("Id").Inspect(), []int64{1, 2, 3})
+	assert.DeepEqual(t, q.Col("Rate").Floats(), []float64{0.5, 1.5, 2.5})
+	assert.Assert(t, q.Col("Comment").Na(0) && q.Col("Comment").Na(1) && !q.Col("Comment").Na(2))
+	assert.Equal(t, q.Col("Comment").Text(2), "third")
+
+	q, err := tables.LazyUnion(a.Lazy(), empty, b.Lazy().Parallel(), tables.UnionCommon).Collect()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, q.Names(), []string{"Id", "Rate"})
+	assert.Equal(t, q.Len(), 3)
+
+	_, err = tables.LazyUnion(a, b, tables.UnionStrict).Collect()
+	assert.ErrorContains(t, err, "does not have column Comment")
+
+	c := tables.New([]struct{ Id string }{{"x"}})
+	_, err = tables.LazyUnion(a, c).Collect()
+	assert.ErrorContains(t, err, "incompatible types")
+
+	n, err := tables.LazyUnion(a, a, a).First(4).Count()
+	assert.NilError(t, err)
+	assert.Equal(t, n, 4)
+}
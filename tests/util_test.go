@@ -110,6 +110,14 @@ func Test_Bits5(t *testing.T) {
 	assert.Assert(t, q.Len() == 127)
 	q = b.Slice(0, 127)
 	assert.Assert(t, q.Len() == 1)
+	q = b.Slice(3, 3)
+	assert.Assert(t, q.Len() == 0)
+	q = b.Slice(64, 128)
+	assert.Assert(t, q.Len() == 64 && q.Bit(63))
+	q = b.Slice(66, 130)
+	assert.Assert(t, q.Len() == 64 && q.Bit(61) && q.Bit(63))
+	q = b.Slice(128, 130)
+	assert.Assert(t, q.Len() == 2)
 }
 
 func Test_Convert(t *testing.T) {
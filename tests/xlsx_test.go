@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"go4ml.xyz/base/fu"
+	"go4ml.xyz/base/tables"
+	"go4ml.xyz/base/tables/csv"
+	"go4ml.xyz/base/tables/xlsx"
+	"go4ml.xyz/iokit"
+	"gotest.tools/assert"
+	"testing"
+	"time"
+)
+
+func Test_Xlsx1(t *testing.T) {
+	ts := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+	q := tables.New([]struct {
+		Name   string
+		Age    int
+		Rate   float64
+		Active bool
+		Since  time.Time
+	}{
+		{"Ivanov <&>", 32, 1.5, true, ts},
+		{"Petrov", 44, -0.25, false, ts.AddDate(0, 1, 0)},
+	})
+	path := "/tmp/go-tables-test.xlsx"
+	err := xlsx.Write(q, iokit.File(path), xlsx.Sheet("People"))
+	assert.NilError(t, err)
+
+	x, err := xlsx.Read(iokit.File(path),
+		xlsx.Sheet("People"),
+		csv.Int("Age"),
+		csv.Float64("Rate").As("Value"),
+		csv.Time("Since"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"Name", "Age", "Value", "Active", "Since"})
+	assert.DeepEqual(t, x.Col("Name").Strings(), []string{"Ivanov <&>", "Petrov"})
+	assert.DeepEqual(t, x.Col("Age").Ints(), []int{32, 44})
+	assert.DeepEqual(t, x.Col("Value").Floats(), []float64{1.5, -0.25})
+	assert.DeepEqual(t, x.Col("Active").Strings(), []string{"true", "false"})
+	assert.Assert(t, x.Col("Since").Index(0).Interface().(time.Time).Equal(ts))
+
+	_, err = xlsx.Read(iokit.File(path), xlsx.Sheet("Unknown"))
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+func Test_Xlsx2(t *testing.T) {
+	bf := bytes.Buffer{}
+	zw := zip.NewWriter(&bf)
+	for _, f := range []struct{ name, content string }{
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="First" sheetId="1" r:id="rId1"/><sheet name="Second" sheetId="2" r:id="rId2"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+			<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+			<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+			<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+			</Relationships>`},
+		{"xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<si><t>Text</t></si><si><t>Date</t></si><si><t>Count</t></si>
+			<si><r><t>rich </t></r><r><t>text</t></r></si><si><t>plain</t></si></sst>`},
+		{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/></numFmts>
+			<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="2"/></cellXfs></styleSheet>`},
+		{"xl/worksheets/sheet1.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2" s="1"><v>46313</v></c><c r="C2" s="2"><v>1.5</v></c></row>
+			<row r="4"><c r="A4" t="inlineStr"><is><t>inline</t></is></c><c r="C4"><v>3</v></c></row>
+			<row r="5"><c r="B5" s="1"><v>46314.5</v></c><c r="C5" t="e"><v>#DIV/0!</v></c></row>
+			</sheetData></worksheet>`},
+		{"xl/worksheets/sheet2.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>4</v></c><c r="C1" t="str"><v>z</v></c></row>
+			<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="b"><v>1</v></c><c r="C2"><v>2</v></c></row>
+			</sheetData></worksheet>`},
+	} {
+		w, err := zw.Create(f.name)
+		assert.NilError(t, err)
+		_, err = w.Write([]byte(f.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zw.Close())
+
+	x, err := xlsx.Read(bytes.NewReader(bf.Bytes()), csv.Float64("Count"), csv.Time("Date"))
+	assert.NilError(t, err)
+	assert.Assert(t, x.Len() == 3)
+	assert.DeepEqual(t, x.Col("Text").Strings(), []string{"rich text", "inline", ""})
+	assert.Assert(t, x.Col("Text").Na(2))
+	assert.Equal(t, x.Col("Date").Index(0).Interface(), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))
+	assert.Assert(t, x.Col("Date").Na(1))
+	assert.Equal(t, x.Col("Date").Index(2).Interface(), time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+	assert.DeepEqual(t, x.Col("Count").Floats()[:2], []float64{1.5, 3})
+	assert.Assert(t, x.Col("Count").Na(2))
+
+	x, err = xlsx.Read(bytes.NewReader(bf.Bytes()), xlsx.SheetIndex(1), csv.Int("z"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, x.Names(), []string{"plain", "B", "z"})
+	assert.DeepEqual(t, fu.MapInterface(x.Row(0)), map[string]interface{}{"plain": "1", "B": "true", "z": 2})
+}